@@ -42,15 +42,55 @@ const (
 	// ENFORCEMENT_FEATURE_UNSPECIFIED is the default/unspecified value for
 	// EnforcementFeature. Asserting this feature has no effect.
 	EnforcementFeature_ENFORCEMENT_FEATURE_UNSPECIFIED EnforcementFeature = 0
+	// ENFORCEMENT_FEATURE_SESSION_MFA indicates that the PEP can enforce
+	// per-session MFA challenges.
+	EnforcementFeature_ENFORCEMENT_FEATURE_SESSION_MFA EnforcementFeature = 1
+	// ENFORCEMENT_FEATURE_DEVICE_TRUST indicates that the PEP can enforce
+	// device trust requirements.
+	EnforcementFeature_ENFORCEMENT_FEATURE_DEVICE_TRUST EnforcementFeature = 2
+	// ENFORCEMENT_FEATURE_SESSION_RECORDING indicates that the PEP can record
+	// sessions.
+	EnforcementFeature_ENFORCEMENT_FEATURE_SESSION_RECORDING EnforcementFeature = 3
+	// ENFORCEMENT_FEATURE_JOIN_SESSION_MODERATION indicates that the PEP can
+	// enforce moderated session policies for session joining.
+	EnforcementFeature_ENFORCEMENT_FEATURE_JOIN_SESSION_MODERATION EnforcementFeature = 4
+	// ENFORCEMENT_FEATURE_PORT_FORWARDING indicates that the PEP can enforce
+	// port forwarding restrictions.
+	EnforcementFeature_ENFORCEMENT_FEATURE_PORT_FORWARDING EnforcementFeature = 5
+	// ENFORCEMENT_FEATURE_FILE_TRANSFER indicates that the PEP can enforce
+	// file transfer restrictions.
+	EnforcementFeature_ENFORCEMENT_FEATURE_FILE_TRANSFER EnforcementFeature = 6
+	// ENFORCEMENT_FEATURE_DESKTOP_CLIPBOARD indicates that the PEP can enforce
+	// desktop clipboard sharing restrictions.
+	EnforcementFeature_ENFORCEMENT_FEATURE_DESKTOP_CLIPBOARD EnforcementFeature = 7
+	// ENFORCEMENT_FEATURE_DESKTOP_DIRECTORY_SHARING indicates that the PEP can
+	// enforce desktop directory sharing restrictions.
+	EnforcementFeature_ENFORCEMENT_FEATURE_DESKTOP_DIRECTORY_SHARING EnforcementFeature = 8
 )
 
 // Enum value maps for EnforcementFeature.
 var (
 	EnforcementFeature_name = map[int32]string{
 		0: "ENFORCEMENT_FEATURE_UNSPECIFIED",
+		1: "ENFORCEMENT_FEATURE_SESSION_MFA",
+		2: "ENFORCEMENT_FEATURE_DEVICE_TRUST",
+		3: "ENFORCEMENT_FEATURE_SESSION_RECORDING",
+		4: "ENFORCEMENT_FEATURE_JOIN_SESSION_MODERATION",
+		5: "ENFORCEMENT_FEATURE_PORT_FORWARDING",
+		6: "ENFORCEMENT_FEATURE_FILE_TRANSFER",
+		7: "ENFORCEMENT_FEATURE_DESKTOP_CLIPBOARD",
+		8: "ENFORCEMENT_FEATURE_DESKTOP_DIRECTORY_SHARING",
 	}
 	EnforcementFeature_value = map[string]int32{
-		"ENFORCEMENT_FEATURE_UNSPECIFIED": 0,
+		"ENFORCEMENT_FEATURE_UNSPECIFIED":               0,
+		"ENFORCEMENT_FEATURE_SESSION_MFA":               1,
+		"ENFORCEMENT_FEATURE_DEVICE_TRUST":              2,
+		"ENFORCEMENT_FEATURE_SESSION_RECORDING":         3,
+		"ENFORCEMENT_FEATURE_JOIN_SESSION_MODERATION":   4,
+		"ENFORCEMENT_FEATURE_PORT_FORWARDING":           5,
+		"ENFORCEMENT_FEATURE_FILE_TRANSFER":             6,
+		"ENFORCEMENT_FEATURE_DESKTOP_CLIPBOARD":         7,
+		"ENFORCEMENT_FEATURE_DESKTOP_DIRECTORY_SHARING": 8,
 	}
 )
 
@@ -81,13 +121,74 @@ func (EnforcementFeature) EnumDescriptor() ([]byte, []int) {
 	return file_teleport_decision_v1alpha1_enforcement_feature_proto_rawDescGZIP(), []int{0}
 }
 
+// PEPCapabilities describes the enforcement features a Policy Enforcement
+// Point is asserting support for, so that the Decision service can refuse to
+// hand back a decision that a PEP has no way of actually enforcing. It is a
+// standalone message: no evaluation request message carries it as a field.
+type PEPCapabilities struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// asserted_features lists the enforcement features the calling PEP
+	// supports. A feature absent from this list is treated as unsupported,
+	// even if the PEP is simply out of date.
+	AssertedFeatures []EnforcementFeature `protobuf:"varint,1,rep,packed,name=asserted_features,json=assertedFeatures,proto3,enum=teleport.decision.v1alpha1.EnforcementFeature" json:"asserted_features,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PEPCapabilities) Reset() {
+	*x = PEPCapabilities{}
+	mi := &file_teleport_decision_v1alpha1_enforcement_feature_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PEPCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PEPCapabilities) ProtoMessage() {}
+
+func (x *PEPCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_teleport_decision_v1alpha1_enforcement_feature_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PEPCapabilities.ProtoReflect.Descriptor instead.
+func (*PEPCapabilities) Descriptor() ([]byte, []int) {
+	return file_teleport_decision_v1alpha1_enforcement_feature_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PEPCapabilities) GetAssertedFeatures() []EnforcementFeature {
+	if x != nil {
+		return x.AssertedFeatures
+	}
+	return nil
+}
+
 var File_teleport_decision_v1alpha1_enforcement_feature_proto protoreflect.FileDescriptor
 
 const file_teleport_decision_v1alpha1_enforcement_feature_proto_rawDesc = "" +
 	"\n" +
-	"4teleport/decision/v1alpha1/enforcement_feature.proto\x12\x1ateleport.decision.v1alpha1*9\n" +
+	"4teleport/decision/v1alpha1/enforcement_feature.proto\x12\x1ateleport.decision.v1alpha1\"n\n" +
+	"\x0fPEPCapabilities\x12[\n" +
+	"\x11asserted_features\x18\x01 \x03(\x0e2..teleport.decision.v1alpha1.EnforcementFeatureR\x10assertedFeatures*\x8e\x03\n" +
 	"\x12EnforcementFeature\x12#\n" +
-	"\x1fENFORCEMENT_FEATURE_UNSPECIFIED\x10\x00BZZXgithub.com/gravitational/teleport/api/gen/proto/go/teleport/decision/v1alpha1;decisionpbb\x06proto3"
+	"\x1fENFORCEMENT_FEATURE_UNSPECIFIED\x10\x00\x12#\n" +
+	"\x1fENFORCEMENT_FEATURE_SESSION_MFA\x10\x01\x12$\n" +
+	" ENFORCEMENT_FEATURE_DEVICE_TRUST\x10\x02\x12)\n" +
+	"%ENFORCEMENT_FEATURE_SESSION_RECORDING\x10\x03\x12/\n" +
+	"+ENFORCEMENT_FEATURE_JOIN_SESSION_MODERATION\x10\x04\x12'\n" +
+	"#ENFORCEMENT_FEATURE_PORT_FORWARDING\x10\x05\x12%\n" +
+	"!ENFORCEMENT_FEATURE_FILE_TRANSFER\x10\x06\x12)\n" +
+	"%ENFORCEMENT_FEATURE_DESKTOP_CLIPBOARD\x10\x07\x121\n" +
+	"-ENFORCEMENT_FEATURE_DESKTOP_DIRECTORY_SHARING\x10\x08BZZXgithub.com/gravitational/teleport/api/gen/proto/go/teleport/decision/v1alpha1;decisionpbb\x06proto3"
 
 var (
 	file_teleport_decision_v1alpha1_enforcement_feature_proto_rawDescOnce sync.Once
@@ -102,15 +203,18 @@ func file_teleport_decision_v1alpha1_enforcement_feature_proto_rawDescGZIP() []b
 }
 
 var file_teleport_decision_v1alpha1_enforcement_feature_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_teleport_decision_v1alpha1_enforcement_feature_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
 var file_teleport_decision_v1alpha1_enforcement_feature_proto_goTypes = []any{
-	(EnforcementFeature)(0), // 0: teleport.decision.v1alpha1.EnforcementFeature
+	(*PEPCapabilities)(nil), // 0: teleport.decision.v1alpha1.PEPCapabilities
+	(EnforcementFeature)(0), // 1: teleport.decision.v1alpha1.EnforcementFeature
 }
 var file_teleport_decision_v1alpha1_enforcement_feature_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: teleport.decision.v1alpha1.PEPCapabilities.asserted_features:type_name -> teleport.decision.v1alpha1.EnforcementFeature
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_teleport_decision_v1alpha1_enforcement_feature_proto_init() }
@@ -124,13 +228,14 @@ func file_teleport_decision_v1alpha1_enforcement_feature_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_teleport_decision_v1alpha1_enforcement_feature_proto_rawDesc), len(file_teleport_decision_v1alpha1_enforcement_feature_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   0,
+			NumMessages:   1,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_teleport_decision_v1alpha1_enforcement_feature_proto_goTypes,
 		DependencyIndexes: file_teleport_decision_v1alpha1_enforcement_feature_proto_depIdxs,
 		EnumInfos:         file_teleport_decision_v1alpha1_enforcement_feature_proto_enumTypes,
+		MessageInfos:      file_teleport_decision_v1alpha1_enforcement_feature_proto_msgTypes,
 	}.Build()
 	File_teleport_decision_v1alpha1_enforcement_feature_proto = out.File
 	file_teleport_decision_v1alpha1_enforcement_feature_proto_goTypes = nil
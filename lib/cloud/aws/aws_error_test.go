@@ -0,0 +1,113 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aws
+
+import (
+	"net/http"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func newV2ResponseError(statusCode int, requestID string, apiErr error) *awshttp.ResponseError {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: statusCode}},
+			Err:      apiErr,
+		},
+		RequestID: requestID,
+	}
+}
+
+// TestWithAWSErrorPreservesV2Metadata verifies withAWSError recovers the
+// service ID, operation name, error code, request ID, and HTTP status code
+// from an SDK v2 error chain and attaches them to the converted error,
+// rather than discarding them once the error is flattened to a string.
+func TestWithAWSErrorPreservesV2Metadata(t *testing.T) {
+	t.Parallel()
+
+	apiErr := &fakeAPIError{code: "AccessDenied", msg: "not authorized"}
+	re := newV2ResponseError(http.StatusForbidden, "req-v2-123", apiErr)
+	opErr := &smithy.OperationError{ServiceID: "IAM", OperationName: "GetRole", Err: re}
+
+	converted := withAWSError(trace.AccessDenied(apiErr.msg), opErr)
+
+	awsErr, ok := GetAWSError(converted)
+	require.True(t, ok)
+	require.Equal(t, "IAM", awsErr.ServiceID)
+	require.Equal(t, "GetRole", awsErr.OperationName)
+	require.Equal(t, "AccessDenied", awsErr.ErrorCode)
+	require.Equal(t, "req-v2-123", awsErr.RequestID)
+	require.Equal(t, http.StatusForbidden, awsErr.HTTPStatusCode)
+	require.True(t, trace.IsAccessDenied(converted))
+}
+
+// TestWithAWSErrorPreservesV1Metadata verifies withAWSError falls back to
+// the SDK v1 awserr.RequestFailure fields when the original error predates
+// the v2 client (e.g. ConvertRequestFailureError's legacy callers).
+func TestWithAWSErrorPreservesV1Metadata(t *testing.T) {
+	t.Parallel()
+
+	original := awserr.NewRequestFailure(
+		awserr.New("AccessDenied", "not authorized", nil),
+		http.StatusForbidden,
+		"req-v1-123",
+	)
+
+	converted := withAWSError(trace.AccessDenied("not authorized"), original)
+
+	awsErr, ok := GetAWSError(converted)
+	require.True(t, ok)
+	require.Equal(t, "AccessDenied", awsErr.ErrorCode)
+	require.Equal(t, "req-v1-123", awsErr.RequestID)
+	require.Equal(t, http.StatusForbidden, awsErr.HTTPStatusCode)
+}
+
+// TestWithAWSErrorNoMetadataReturnsConvertedUnwrapped verifies withAWSError
+// doesn't wrap converted in an AWSError when original carries none of the
+// metadata AWSError tracks, so GetAWSError on a plain error continues to
+// report nothing rather than an all-zero AWSError.
+func TestWithAWSErrorNoMetadataReturnsConvertedUnwrapped(t *testing.T) {
+	t.Parallel()
+
+	converted := trace.AccessDenied("not authorized")
+	got := withAWSError(converted, trace.Errorf("plain error with no AWS metadata"))
+
+	require.Equal(t, converted, got)
+	_, ok := GetAWSError(got)
+	require.False(t, ok)
+}
+
+// TestAWSErrorUnwrapAndIs verifies AWSError stays transparent to
+// trace.Is*/errors.As checks through Unwrap, so callers that only care
+// about the error kind don't need to know about AWSError at all.
+func TestAWSErrorUnwrapAndIs(t *testing.T) {
+	t.Parallel()
+
+	converted := withAWSError(trace.NotFound("no such role"), newV2ResponseError(
+		http.StatusNotFound, "req-456", &fakeAPIError{code: "NoSuchEntity", msg: "no such role"}))
+
+	require.True(t, trace.IsNotFound(converted))
+	require.Equal(t, "no such role", converted.Error())
+}
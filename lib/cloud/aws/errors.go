@@ -29,6 +29,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/redshiftserverless"
+	"github.com/aws/smithy-go"
 	"github.com/gravitational/trace"
 )
 
@@ -40,7 +41,7 @@ import (
 func ConvertRequestFailureError(err error) error {
 	var requestErr awserr.RequestFailure
 	if errors.As(err, &requestErr) {
-		return convertRequestFailureErrorFromStatusCode(requestErr.StatusCode(), requestErr)
+		return withAWSError(convertRequestFailureErrorFromStatusCode(requestErr.StatusCode(), requestErr), err)
 	}
 	return ConvertRequestFailureErrorV2(err)
 }
@@ -51,15 +52,118 @@ func ConvertRequestFailureError(err error) error {
 func ConvertRequestFailureErrorV2(err error) error {
 	var re *awshttp.ResponseError
 	if errors.As(err, &re) {
-		return convertRequestFailureErrorFromStatusCode(re.HTTPStatusCode(), re.Err)
+		return withAWSError(convertRequestFailureErrorFromStatusCode(re.HTTPStatusCode(), re.Err), err)
 	}
 	return err
 }
 
+// AWSError carries metadata about the underlying AWS API failure that
+// [ConvertRequestFailureErrorV2] and [ConvertIAMv2Error] would otherwise
+// discard once they flatten it down to an error string. Callers that want
+// to render a precise diagnostic (e.g. "AccessDenied on iam:GetRole,
+// request-id abc") should recover it with [GetAWSError].
+type AWSError struct {
+	// ServiceID is the AWS service the request was made against, e.g. "IAM".
+	ServiceID string
+	// OperationName is the API operation that failed, e.g. "GetRole".
+	OperationName string
+	// ErrorCode is the AWS error code, e.g. "AccessDenied".
+	ErrorCode string
+	// RequestID is the AWS request ID, useful when asking AWS support to
+	// investigate.
+	RequestID string
+	// HTTPStatusCode is the HTTP status the AWS API responded with.
+	HTTPStatusCode int
+
+	err error
+}
+
+// Error implements the error interface by deferring to the wrapped trace
+// error so that [AWSError] is transparent to existing error-message checks.
+func (e *AWSError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the trace error produced by the status-code classification,
+// so trace.Is* predicates and errors.As keep working through [AWSError].
+func (e *AWSError) Unwrap() error {
+	return e.err
+}
+
+// withAWSError attaches the AWS metadata found in original to converted,
+// returning converted unmodified if original carries none.
+func withAWSError(converted, original error) error {
+	awsErr := &AWSError{err: converted}
+
+	var re *awshttp.ResponseError
+	if errors.As(original, &re) {
+		awsErr.HTTPStatusCode = re.HTTPStatusCode()
+		awsErr.RequestID = re.ServiceRequestID()
+	}
+
+	var oe *smithy.OperationError
+	if errors.As(original, &oe) {
+		awsErr.ServiceID = oe.ServiceID
+		awsErr.OperationName = oe.OperationName
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(original, &apiErr) {
+		awsErr.ErrorCode = apiErr.ErrorCode()
+	}
+
+	// Fall back to the SDK v1 equivalents for legacy awserr.RequestFailure
+	// callers such as ConvertRequestFailureError.
+	var rf awserr.RequestFailure
+	if errors.As(original, &rf) {
+		awsErr.RequestID = rf.RequestID()
+		awsErr.HTTPStatusCode = rf.StatusCode()
+	}
+	var awsErrV1 awserr.Error
+	if awsErr.ErrorCode == "" && errors.As(original, &awsErrV1) {
+		awsErr.ErrorCode = awsErrV1.Code()
+	}
+
+	if awsErr.ServiceID == "" && awsErr.OperationName == "" && awsErr.ErrorCode == "" &&
+		awsErr.RequestID == "" && awsErr.HTTPStatusCode == 0 {
+		return converted
+	}
+	return awsErr
+}
+
+// GetAWSError returns the [AWSError] carried by err, if any. Use this to
+// recover service, operation, error code, request ID, and HTTP status that
+// [ConvertRequestFailureErrorV2] and [ConvertIAMv2Error] attach to the trace
+// error they return.
+func GetAWSError(err error) (*AWSError, bool) {
+	var awsErr *AWSError
+	if errors.As(err, &awsErr) {
+		return awsErr, true
+	}
+	return nil, false
+}
+
 var (
 	ecsClusterNotFoundException *ecstypes.ClusterNotFoundException
 )
 
+// throttlingErrorCodes are AWS error codes that SDKs use to signal that a
+// request was throttled, regardless of which HTTP status they were sent
+// with (most services use 429, but some return them on a 400).
+var throttlingErrorCodes = []string{
+	"TooManyRequestsException",
+	"ThrottlingException",
+	"RequestLimitExceeded",
+	"ProvisionedThroughputExceededException",
+}
+
+// transientErrorCodes are AWS error codes that signal a momentary service
+// outage rather than a problem with the request itself.
+var transientErrorCodes = []string{
+	"ServiceUnavailable",
+	"InternalFailure",
+}
+
 func convertRequestFailureErrorFromStatusCode(statusCode int, requestErr error) error {
 	switch statusCode {
 	case http.StatusForbidden:
@@ -68,6 +172,8 @@ func convertRequestFailureErrorFromStatusCode(statusCode int, requestErr error)
 		return trace.AlreadyExists(requestErr.Error())
 	case http.StatusNotFound:
 		return trace.NotFound(requestErr.Error())
+	case http.StatusTooManyRequests:
+		return trace.LimitExceeded(requestErr.Error())
 	case http.StatusBadRequest:
 		// Some services like memorydb, redshiftserverless may return 400 with
 		// "AccessDeniedException" instead of 403.
@@ -78,11 +184,53 @@ func convertRequestFailureErrorFromStatusCode(statusCode int, requestErr error)
 		if strings.Contains(requestErr.Error(), ecsClusterNotFoundException.ErrorCode()) {
 			return trace.NotFound(requestErr.Error())
 		}
+
+		// Some services (e.g. DynamoDB) signal throttling with a 400 and a
+		// ProvisionedThroughputExceededException-style code instead of 429.
+		if hasErrorCode(requestErr, throttlingErrorCodes) {
+			return trace.LimitExceeded(requestErr.Error())
+		}
+	}
+
+	if statusCode >= http.StatusInternalServerError && hasErrorCode(requestErr, transientErrorCodes) {
+		return trace.ConnectionProblem(requestErr, requestErr.Error())
 	}
 
 	return requestErr // Return unmodified.
 }
 
+// hasErrorCode reports whether requestErr carries one of codes as its AWS
+// error code. It checks the SDK v2 smithy.APIError first and falls back to a
+// substring match on the error message for SDK v1 errors and cases where the
+// code was not preserved as a distinct field.
+func hasErrorCode(requestErr error, codes []string) bool {
+	var apiErr smithy.APIError
+	if errors.As(requestErr, &apiErr) {
+		code := apiErr.ErrorCode()
+		for _, c := range codes {
+			if code == c {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, c := range codes {
+		if strings.Contains(requestErr.Error(), c) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryable returns true if err represents a transient AWS condition -
+// throttling, a momentary service outage, or an explicitly retryable
+// condition like expired credentials - that discovery/provisioning retry
+// loops should back off and retry rather than give up on.
+func IsRetryable(err error) bool {
+	return trace.IsLimitExceeded(err) || trace.IsConnectionProblem(err) || trace.IsRetryError(err)
+}
+
 // ConvertIAMError converts common errors from IAM clients to trace errors.
 func ConvertIAMError(err error) error {
 	// By error code.
@@ -117,22 +265,22 @@ func ConvertIAMv2Error(err error) error {
 
 	var entityExistsError *iamtypes.EntityAlreadyExistsException
 	if errors.As(err, &entityExistsError) {
-		return trace.AlreadyExists(*entityExistsError.Message)
+		return withAWSError(trace.AlreadyExists(*entityExistsError.Message), err)
 	}
 
 	var entityNotFound *iamtypes.NoSuchEntityException
 	if errors.As(err, &entityNotFound) {
-		return trace.NotFound(*entityNotFound.Message)
+		return withAWSError(trace.NotFound(*entityNotFound.Message), err)
 	}
 
 	var malformedPolicyDocument *iamtypes.MalformedPolicyDocumentException
 	if errors.As(err, &malformedPolicyDocument) {
-		return trace.BadParameter(*malformedPolicyDocument.Message)
+		return withAWSError(trace.BadParameter(*malformedPolicyDocument.Message), err)
 	}
 
 	var re *awshttp.ResponseError
 	if errors.As(err, &re) {
-		return convertRequestFailureErrorFromStatusCode(re.HTTPStatusCode(), re.Err)
+		return withAWSError(convertRequestFailureErrorFromStatusCode(re.HTTPStatusCode(), re.Err), err)
 	}
 
 	return err
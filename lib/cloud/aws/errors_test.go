@@ -0,0 +1,144 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aws
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIError is a minimal smithy.APIError implementation so tests can
+// exercise hasErrorCode/convertRequestFailureErrorFromStatusCode's
+// error-code branches without constructing a real AWS SDK response.
+type fakeAPIError struct {
+	code string
+	msg  string
+}
+
+func (e *fakeAPIError) Error() string                 { return e.msg }
+func (e *fakeAPIError) ErrorCode() string             { return e.code }
+func (e *fakeAPIError) ErrorMessage() string          { return e.msg }
+func (e *fakeAPIError) ErrorFault() smithy.ErrorFault { return smithy.FaultUnknown }
+
+func TestConvertRequestFailureErrorFromStatusCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		statusCode      int
+		requestErr      error
+		assertErr       func(t *testing.T, err error)
+		assertRetryable bool
+	}{
+		{
+			name:       "403 forbidden becomes access denied",
+			statusCode: http.StatusForbidden,
+			requestErr: &fakeAPIError{code: "AccessDenied", msg: "access denied"},
+			assertErr: func(t *testing.T, err error) {
+				require.True(t, trace.IsAccessDenied(err))
+			},
+		},
+		{
+			name:       "409 conflict becomes already exists",
+			statusCode: http.StatusConflict,
+			requestErr: &fakeAPIError{code: "ResourceInUseException", msg: "in use"},
+			assertErr: func(t *testing.T, err error) {
+				require.True(t, trace.IsAlreadyExists(err))
+			},
+		},
+		{
+			name:       "404 becomes not found",
+			statusCode: http.StatusNotFound,
+			requestErr: &fakeAPIError{code: "NotFound", msg: "not found"},
+			assertErr: func(t *testing.T, err error) {
+				require.True(t, trace.IsNotFound(err))
+			},
+		},
+		{
+			name:       "429 becomes limit exceeded",
+			statusCode: http.StatusTooManyRequests,
+			requestErr: &fakeAPIError{code: "TooManyRequestsException", msg: "slow down"},
+			assertErr: func(t *testing.T, err error) {
+				require.True(t, trace.IsLimitExceeded(err))
+			},
+			assertRetryable: true,
+		},
+		{
+			name:       "400 with a throttling code becomes limit exceeded",
+			statusCode: http.StatusBadRequest,
+			requestErr: &fakeAPIError{code: "ProvisionedThroughputExceededException", msg: "throttled"},
+			assertErr: func(t *testing.T, err error) {
+				require.True(t, trace.IsLimitExceeded(err))
+			},
+			assertRetryable: true,
+		},
+		{
+			name:       "plain 400 is returned unmodified",
+			statusCode: http.StatusBadRequest,
+			requestErr: &fakeAPIError{code: "ValidationException", msg: "bad request"},
+			assertErr: func(t *testing.T, err error) {
+				require.False(t, trace.IsLimitExceeded(err))
+				require.False(t, trace.IsAccessDenied(err))
+			},
+		},
+		{
+			name:       "500 with a transient code becomes connection problem",
+			statusCode: http.StatusInternalServerError,
+			requestErr: &fakeAPIError{code: "InternalFailure", msg: "oops"},
+			assertErr: func(t *testing.T, err error) {
+				require.True(t, trace.IsConnectionProblem(err))
+			},
+			assertRetryable: true,
+		},
+		{
+			name:       "500 without a transient code is returned unmodified",
+			statusCode: http.StatusInternalServerError,
+			requestErr: &fakeAPIError{code: "SomethingElse", msg: "oops"},
+			assertErr: func(t *testing.T, err error) {
+				require.False(t, trace.IsConnectionProblem(err))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := convertRequestFailureErrorFromStatusCode(tt.statusCode, tt.requestErr)
+			tt.assertErr(t, err)
+			require.Equal(t, tt.assertRetryable, IsRetryable(err))
+		})
+	}
+}
+
+func TestHasErrorCode(t *testing.T) {
+	t.Parallel()
+
+	codes := []string{"ThrottlingException", "RequestLimitExceeded"}
+
+	require.True(t, hasErrorCode(&fakeAPIError{code: "ThrottlingException"}, codes))
+	require.False(t, hasErrorCode(&fakeAPIError{code: "SomethingElse"}, codes))
+
+	// Falls back to a substring match when requestErr isn't a smithy.APIError.
+	require.True(t, hasErrorCode(trace.Errorf("operation failed: RequestLimitExceeded"), codes))
+	require.False(t, hasErrorCode(trace.Errorf("operation failed: ValidationException"), codes))
+}
@@ -0,0 +1,39 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package middleware provides helpers that wrap AWS SDK client calls so that
+// discovery, db access, and app access call sites get consistent error
+// conversion and tracing without duplicating the decoration at each call
+// site.
+package middleware
+
+import (
+	"context"
+
+	"github.com/gravitational/teleport/lib/cloud/aws"
+)
+
+// WithErrorConversion calls fn and, if it returns an error, converts it to a
+// trace error via [aws.ConvertRequestFailureErrorV2Ctx] and records it on the
+// span current in ctx (if any). Call sites that make AWS SDK v2 requests
+// should wrap them with this instead of calling the converter directly, so
+// that the resulting error spans are consistent across discovery, db access,
+// and app access.
+func WithErrorConversion(ctx context.Context, fn func() error) error {
+	return aws.ConvertRequestFailureErrorV2Ctx(ctx, fn())
+}
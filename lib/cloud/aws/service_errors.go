@@ -0,0 +1,93 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aws
+
+import (
+	"errors"
+
+	"github.com/aws/smithy-go"
+	"github.com/gravitational/trace"
+)
+
+// errorCodeMapping maps an AWS error code (as reported by
+// [smithy.APIError.ErrorCode]) to the trace error constructor it should be
+// converted to.
+type errorCodeMapping map[string]func(msg string) error
+
+// convertByErrorCode converts err to a trace error using mapping, looked up
+// by the AWS error code extracted from err's [smithy.APIError]. If err is
+// not a smithy.APIError, or its code has no entry in mapping, it falls back
+// to [ConvertRequestFailureErrorV2].
+func convertByErrorCode(err error, mapping errorCodeMapping) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return ConvertRequestFailureErrorV2(err)
+	}
+
+	convert, ok := mapping[apiErr.ErrorCode()]
+	if !ok {
+		return ConvertRequestFailureErrorV2(err)
+	}
+	return withAWSError(convert(apiErr.ErrorMessage()), err)
+}
+
+var s3ErrorCodes = errorCodeMapping{
+	"NoSuchBucket":            func(msg string) error { return trace.NotFound(msg) },
+	"NoSuchKey":               func(msg string) error { return trace.NotFound(msg) },
+	"BucketAlreadyOwnedByYou": func(msg string) error { return trace.AlreadyExists(msg) },
+	"SlowDown":                func(msg string) error { return trace.LimitExceeded(msg) },
+}
+
+// ConvertS3Error converts common errors from S3 clients to trace errors.
+func ConvertS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return convertByErrorCode(err, s3ErrorCodes)
+}
+
+var stsErrorCodes = errorCodeMapping{
+	// ExpiredTokenException is returned when the temporary credentials used
+	// for the request have expired; the caller should refresh them and
+	// retry, so this maps to trace.Retry rather than ConnectionProblem.
+	"ExpiredTokenException":   func(msg string) error { return trace.Retry(nil, msg) },
+	"RegionDisabledException": func(msg string) error { return trace.AccessDenied(msg) },
+}
+
+// ConvertSTSError converts common errors from STS clients to trace errors.
+func ConvertSTSError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return convertByErrorCode(err, stsErrorCodes)
+}
+
+var dynamoDBErrorCodes = errorCodeMapping{
+	"ConditionalCheckFailedException":        func(msg string) error { return trace.CompareFailed(msg) },
+	"ProvisionedThroughputExceededException": func(msg string) error { return trace.LimitExceeded(msg) },
+}
+
+// ConvertDynamoDBError converts common errors from DynamoDB clients to trace
+// errors.
+func ConvertDynamoDBError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return convertByErrorCode(err, dynamoDBErrorCodes)
+}
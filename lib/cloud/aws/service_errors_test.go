@@ -0,0 +1,76 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aws
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertS3Error(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ConvertS3Error(nil))
+
+	tests := []struct {
+		name      string
+		err       error
+		assertErr require.BoolAssertionFunc
+	}{
+		{"no such bucket", &fakeAPIError{code: "NoSuchBucket", msg: "gone"}, require.True},
+		{"no such key", &fakeAPIError{code: "NoSuchKey", msg: "gone"}, require.True},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.assertErr(t, trace.IsNotFound(ConvertS3Error(tt.err)))
+		})
+	}
+
+	require.True(t, trace.IsAlreadyExists(ConvertS3Error(&fakeAPIError{code: "BucketAlreadyOwnedByYou", msg: "mine"})))
+	require.True(t, trace.IsLimitExceeded(ConvertS3Error(&fakeAPIError{code: "SlowDown", msg: "slow down"})))
+
+	// An unmapped code falls back to status-code classification instead of
+	// being returned as a generic error.
+	unmapped := ConvertS3Error(newV2ResponseError(http.StatusForbidden, "req-1", &fakeAPIError{code: "AccessDenied", msg: "denied"}))
+	require.True(t, trace.IsAccessDenied(unmapped))
+}
+
+func TestConvertSTSError(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ConvertSTSError(nil))
+
+	expired := ConvertSTSError(&fakeAPIError{code: "ExpiredTokenException", msg: "expired"})
+	require.True(t, trace.IsRetryError(expired))
+	require.True(t, IsRetryable(expired))
+	require.True(t, trace.IsAccessDenied(ConvertSTSError(&fakeAPIError{code: "RegionDisabledException", msg: "disabled"})))
+}
+
+func TestConvertDynamoDBError(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ConvertDynamoDBError(nil))
+
+	require.True(t, trace.IsCompareFailed(ConvertDynamoDBError(&fakeAPIError{code: "ConditionalCheckFailedException", msg: "mismatch"})))
+	require.True(t, trace.IsLimitExceeded(ConvertDynamoDBError(&fakeAPIError{code: "ProvisionedThroughputExceededException", msg: "throttled"})))
+}
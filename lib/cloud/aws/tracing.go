@@ -0,0 +1,76 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aws
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// ConvertRequestFailureErrorCtx is the context-aware variant of
+// [ConvertRequestFailureError]. If ctx carries a recording span, the
+// extracted AWS service, operation, error code, HTTP status, and request ID
+// are recorded as span attributes and the error is attached to the span, so
+// an operator can trace e.g. a "403 AccessDenied on DescribeDBInstances" all
+// the way from the UI back to the exact IAM principal.
+func ConvertRequestFailureErrorCtx(ctx context.Context, err error) error {
+	return recordAWSErrorSpan(ctx, ConvertRequestFailureError(err))
+}
+
+// ConvertRequestFailureErrorV2Ctx is the context-aware variant of
+// [ConvertRequestFailureErrorV2].
+func ConvertRequestFailureErrorV2Ctx(ctx context.Context, err error) error {
+	return recordAWSErrorSpan(ctx, ConvertRequestFailureErrorV2(err))
+}
+
+// ConvertIAMv2ErrorCtx is the context-aware variant of [ConvertIAMv2Error].
+func ConvertIAMv2ErrorCtx(ctx context.Context, err error) error {
+	return recordAWSErrorSpan(ctx, ConvertIAMv2Error(err))
+}
+
+// recordAWSErrorSpan records the [AWSError] metadata carried by err, if any,
+// as attributes on the span current in ctx, and marks the span as having
+// recorded an error. It returns err unmodified.
+func recordAWSErrorSpan(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	span := oteltrace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return err
+	}
+
+	span.RecordError(err)
+	awsErr, ok := GetAWSError(err)
+	if !ok {
+		return err
+	}
+
+	span.SetAttributes(
+		attribute.String("aws.service_id", awsErr.ServiceID),
+		attribute.String("aws.operation_name", awsErr.OperationName),
+		attribute.String("aws.error_code", awsErr.ErrorCode),
+		attribute.String("aws.request_id", awsErr.RequestID),
+		attribute.Int("aws.http_status_code", awsErr.HTTPStatusCode),
+	)
+	return err
+}
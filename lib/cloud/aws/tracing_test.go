@@ -0,0 +1,89 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aws
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestConvertRequestFailureErrorCtxRecordsSpan verifies that, given a
+// recording span in ctx, converting an AWS error also records the AWSError
+// metadata as span attributes and marks the span as erroring.
+func TestConvertRequestFailureErrorCtxRecordsSpan(t *testing.T) {
+	t.Parallel()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "DescribeDBInstances")
+
+	original := newV2ResponseError(http.StatusForbidden, "req-789", &fakeAPIError{code: "AccessDenied", msg: "not authorized"})
+	err := ConvertRequestFailureErrorV2Ctx(ctx, original)
+	span.End()
+
+	require.True(t, trace.IsAccessDenied(err))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	require.NotEmpty(t, spans[0].Events())
+
+	attrs := make(map[string]string)
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	require.Equal(t, "AccessDenied", attrs["aws.error_code"])
+	require.Equal(t, "req-789", attrs["aws.request_id"])
+}
+
+// TestRecordAWSErrorSpanNoOpWhenNotRecording verifies recordAWSErrorSpan
+// doesn't panic or otherwise misbehave when ctx carries no recording span,
+// which is the common case for a call site that isn't inside a traced
+// request.
+func TestRecordAWSErrorSpanNoOpWhenNotRecording(t *testing.T) {
+	t.Parallel()
+
+	original := newV2ResponseError(http.StatusForbidden, "req-1", &fakeAPIError{code: "AccessDenied", msg: "denied"})
+	err := ConvertRequestFailureErrorV2Ctx(context.Background(), original)
+	require.True(t, trace.IsAccessDenied(err))
+}
+
+// TestRecordAWSErrorSpanNilError verifies recordAWSErrorSpan is a no-op on a
+// nil error, so a successful call traced through ...Ctx doesn't record a
+// spurious error event.
+func TestRecordAWSErrorSpanNilError(t *testing.T) {
+	t.Parallel()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "GetRole")
+
+	err := ConvertIAMv2ErrorCtx(ctx, nil)
+	span.End()
+
+	require.NoError(t, err)
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	require.Empty(t, spans[0].Events())
+}
@@ -0,0 +1,72 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package decision implements support code for evaluating decision requests
+// against the Policy Enforcement Point (PEP) capabilities asserted by the
+// caller.
+package decision
+
+import (
+	"sort"
+	"strings"
+
+	decisionpb "github.com/gravitational/teleport/api/gen/proto/go/teleport/decision/v1alpha1"
+	"github.com/gravitational/trace"
+)
+
+// CheckAssertedFeatures verifies that the PEP asserted every enforcement
+// feature that the matched roles require. It returns a trace.BadParameter
+// error listing the missing features if any are absent, so that a decision
+// evaluation fails loudly instead of being enforced by a PEP that silently
+// cannot apply it (e.g. an older agent asked to require session MFA).
+//
+// ENFORCEMENT_FEATURE_UNSPECIFIED is ignored wherever it appears in
+// required, since it represents "no requirement".
+func CheckAssertedFeatures(required, asserted []decisionpb.EnforcementFeature) error {
+	assertedSet := make(map[decisionpb.EnforcementFeature]struct{}, len(asserted))
+	for _, feature := range asserted {
+		assertedSet[feature] = struct{}{}
+	}
+
+	var missing []decisionpb.EnforcementFeature
+	for _, feature := range required {
+		if feature == decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_UNSPECIFIED {
+			continue
+		}
+		if _, ok := assertedSet[feature]; !ok {
+			missing = append(missing, feature)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(missing))
+	for i, feature := range missing {
+		names[i] = feature.String()
+	}
+	sort.Strings(names)
+	return trace.BadParameter("PEP did not assert required enforcement feature(s): %s", strings.Join(names, ", "))
+}
+
+// CheckPEPCapabilities is CheckAssertedFeatures for the features asserted in
+// a decisionpb.PEPCapabilities message. A nil pep is treated as asserting no
+// features, matching the behavior of an older PEP that predates this message.
+func CheckPEPCapabilities(required []decisionpb.EnforcementFeature, pep *decisionpb.PEPCapabilities) error {
+	return CheckAssertedFeatures(required, pep.GetAssertedFeatures())
+}
@@ -0,0 +1,102 @@
+/*
+ * Teleport
+ * Copyright (C) 2024  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package decision
+
+import (
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	decisionpb "github.com/gravitational/teleport/api/gen/proto/go/teleport/decision/v1alpha1"
+)
+
+func TestCheckAssertedFeatures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		required  []decisionpb.EnforcementFeature
+		asserted  []decisionpb.EnforcementFeature
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name:      "no requirements",
+			required:  nil,
+			asserted:  nil,
+			assertErr: require.NoError,
+		},
+		{
+			name:      "unspecified requirement is ignored",
+			required:  []decisionpb.EnforcementFeature{decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_UNSPECIFIED},
+			asserted:  nil,
+			assertErr: require.NoError,
+		},
+		{
+			name:     "all requirements asserted",
+			required: []decisionpb.EnforcementFeature{decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_SESSION_MFA},
+			asserted: []decisionpb.EnforcementFeature{
+				decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_SESSION_MFA,
+				decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_DEVICE_TRUST,
+			},
+			assertErr: require.NoError,
+		},
+		{
+			name: "missing requirement",
+			required: []decisionpb.EnforcementFeature{
+				decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_SESSION_MFA,
+				decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_DEVICE_TRUST,
+			},
+			asserted: []decisionpb.EnforcementFeature{decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_SESSION_MFA},
+			assertErr: func(t require.TestingT, err error, _ ...interface{}) {
+				require.True(t, trace.IsBadParameter(err))
+				require.ErrorContains(t, err, "ENFORCEMENT_FEATURE_DEVICE_TRUST")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckAssertedFeatures(tt.required, tt.asserted)
+			tt.assertErr(t, err)
+		})
+	}
+}
+
+func TestCheckPEPCapabilities(t *testing.T) {
+	t.Parallel()
+
+	required := []decisionpb.EnforcementFeature{decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_SESSION_MFA}
+
+	t.Run("nil PEPCapabilities asserts nothing", func(t *testing.T) {
+		t.Parallel()
+		err := CheckPEPCapabilities(required, nil)
+		require.True(t, trace.IsBadParameter(err))
+		require.ErrorContains(t, err, "ENFORCEMENT_FEATURE_SESSION_MFA")
+	})
+
+	t.Run("asserted feature satisfies requirement", func(t *testing.T) {
+		t.Parallel()
+		pep := &decisionpb.PEPCapabilities{
+			AssertedFeatures: []decisionpb.EnforcementFeature{decisionpb.EnforcementFeature_ENFORCEMENT_FEATURE_SESSION_MFA},
+		}
+		require.NoError(t, CheckPEPCapabilities(required, pep))
+	})
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2015 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reversetunnel
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/Sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// ServeAgentConn dispatches everything the proxy sends back over sshConn for
+// the lifetime of an agent's connection to it: global requests (listen@teleport
+// / unlisten@teleport) and channel opens (direct-streamlocal@openssh.com).
+// It's meant to be called once, right after an agent completes the SSH client
+// handshake against a proxy (the same point srv.go's HandleNewChan/addConn
+// hooks in on the proxy side), so that tryDialUnix and Listen are actually
+// reachable from a running agent rather than only from this package's tests.
+// ServeAgentConn blocks until chans is closed, which happens when sshConn is
+// closed; callers that want to keep dialing after the tunnel drops should run
+// it in its own goroutine per connection attempt.
+func ServeAgentConn(sshConn ssh.Conn, chans <-chan ssh.NewChannel, reqs <-chan *ssh.Request) {
+	go handleAgentGlobalRequests(sshConn, reqs)
+	handleAgentChannels(chans)
+}
+
+// handleAgentChannels is the agent-side mirror of (*server).HandleNewChan: it
+// runs for the lifetime of the agent's connection to a proxy, dispatching
+// each channel the proxy opens back over that connection to the handler for
+// its type. Unrecognized channel types are rejected with
+// ssh.UnknownChannelType.
+func handleAgentChannels(chans <-chan ssh.NewChannel) {
+	for nch := range chans {
+		switch nch.ChannelType() {
+		case chanDirectStreamLocal:
+			go handleDirectStreamLocal(nch)
+		default:
+			log.Warningf("agent rejecting unknown channel type: %v", nch.ChannelType())
+			nch.Reject(ssh.UnknownChannelType, "unknown channel type")
+		}
+	}
+}
+
+// handleDirectStreamLocal accepts a chanDirectStreamLocal channel opened by
+// the proxy against this agent, dials the requested Unix socket on the
+// agent's own host, and pipes the channel and the socket together until
+// either side closes. This is the receiving half of tunnelSite.tryDialUnix:
+// without it, a proxy's Dial("unix", ...) gets an "unknown channel type"
+// rejection instead of a connection.
+func handleDirectStreamLocal(nch ssh.NewChannel) {
+	var msg streamLocalChannelOpenDirectMsg
+	if err := ssh.Unmarshal(nch.ExtraData(), &msg); err != nil {
+		log.Errorf("failed to unmarshal direct-streamlocal request: %v", err)
+		nch.Reject(ssh.ConnectionFailed, "bad direct-streamlocal request")
+		return
+	}
+
+	conn, err := net.Dial("unix", msg.SocketPath)
+	if err != nil {
+		log.Errorf("failed to dial unix socket %v: %v", msg.SocketPath, err)
+		nch.Reject(ssh.ConnectionFailed, "failed to dial unix socket")
+		return
+	}
+	defer conn.Close()
+
+	ch, reqs, err := nch.Accept()
+	if err != nil {
+		log.Errorf("failed to accept direct-streamlocal channel: %v", err)
+		return
+	}
+	defer ch.Close()
+	go ssh.DiscardRequests(reqs)
+
+	pipeConn(ch, conn)
+}
+
+// pipeConn copies data between an SSH channel and a net.Conn in both
+// directions until one side closes, then returns. Both handleDirectStreamLocal
+// and agentListener.serve use this to bridge a channel opened over the
+// tunnel to a real local connection.
+func pipeConn(ch ssh.Channel, conn net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(ch, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, ch)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// agentListenerRegistry is the agent-side mirror of tunnelSite.listeners: it
+// tracks the local net.Listener bound for each addr a proxy has asked this
+// agent to Listen on, so a later unlisten@teleport request for the same addr
+// can find and close it.
+type agentListenerRegistry struct {
+	sshConn ssh.Conn
+
+	mu        sync.Mutex
+	listeners map[string]*agentListener
+}
+
+// agentListener is a single network listener an agent bound on behalf of a
+// proxy's tunnelSite.Listen call, forwarding every accepted connection back
+// to the proxy as a chanForwardedTransport channel.
+type agentListener struct {
+	network  string
+	addr     string
+	listener net.Listener
+}
+
+// newAgentListenerRegistry returns a registry that answers listen@teleport
+// and unlisten@teleport global requests arriving over sshConn.
+func newAgentListenerRegistry(sshConn ssh.Conn) *agentListenerRegistry {
+	return &agentListenerRegistry{
+		sshConn:   sshConn,
+		listeners: make(map[string]*agentListener),
+	}
+}
+
+// handleAgentGlobalRequests processes the global SSH requests a proxy sends
+// over sshConn for the lifetime of the connection: listen@teleport asks this
+// agent to bind network/addr locally and tunnel back any connections it
+// accepts, and unlisten@teleport asks it to stop. This is the receiving half
+// of tunnelSite.Listen/tunnelListener.Close; without it a proxy's Listen call
+// gets a protocol error instead of a bound listener.
+func handleAgentGlobalRequests(sshConn ssh.Conn, reqs <-chan *ssh.Request) {
+	reg := newAgentListenerRegistry(sshConn)
+	for req := range reqs {
+		switch req.Type {
+		case chanTransportListen:
+			reg.handleListen(req)
+		case chanTransportUnlisten:
+			reg.handleUnlisten(req)
+		default:
+			if req.WantReply {
+				req.Reply(false, nil)
+			}
+		}
+	}
+	reg.closeAll()
+}
+
+// handleListen binds the network/addr carried by req and starts forwarding
+// accepted connections back over reg.sshConn, replying true on success.
+func (reg *agentListenerRegistry) handleListen(req *ssh.Request) {
+	var msg listenRequestMsg
+	if err := ssh.Unmarshal(req.Payload, &msg); err != nil {
+		log.Errorf("failed to unmarshal listen request: %v", err)
+		if req.WantReply {
+			req.Reply(false, nil)
+		}
+		return
+	}
+
+	reg.mu.Lock()
+	if _, exists := reg.listeners[msg.Addr]; exists {
+		reg.mu.Unlock()
+		log.Errorf("already listening on %v/%v", msg.Network, msg.Addr)
+		if req.WantReply {
+			req.Reply(false, nil)
+		}
+		return
+	}
+	reg.mu.Unlock()
+
+	listener, err := net.Listen(msg.Network, msg.Addr)
+	if err != nil {
+		log.Errorf("failed to listen on %v/%v: %v", msg.Network, msg.Addr, err)
+		if req.WantReply {
+			req.Reply(false, nil)
+		}
+		return
+	}
+
+	al := &agentListener{network: msg.Network, addr: msg.Addr, listener: listener}
+	reg.mu.Lock()
+	if _, exists := reg.listeners[msg.Addr]; exists {
+		reg.mu.Unlock()
+		listener.Close()
+		log.Errorf("already listening on %v/%v", msg.Network, msg.Addr)
+		if req.WantReply {
+			req.Reply(false, nil)
+		}
+		return
+	}
+	reg.listeners[msg.Addr] = al
+	reg.mu.Unlock()
+
+	go reg.serve(al)
+
+	if req.WantReply {
+		req.Reply(true, nil)
+	}
+}
+
+// handleUnlisten closes and de-registers the listener bound for the addr
+// carried by req, the mirror image of handleListen.
+func (reg *agentListenerRegistry) handleUnlisten(req *ssh.Request) {
+	var msg listenRequestMsg
+	if err := ssh.Unmarshal(req.Payload, &msg); err != nil {
+		log.Errorf("failed to unmarshal unlisten request: %v", err)
+		if req.WantReply {
+			req.Reply(false, nil)
+		}
+		return
+	}
+
+	reg.mu.Lock()
+	al, ok := reg.listeners[msg.Addr]
+	if ok {
+		delete(reg.listeners, msg.Addr)
+	}
+	reg.mu.Unlock()
+
+	if ok {
+		al.listener.Close()
+	}
+	if req.WantReply {
+		req.Reply(true, nil)
+	}
+}
+
+// serve accepts connections on al.listener until it's closed, forwarding
+// each one to the proxy over a fresh chanForwardedTransport channel.
+func (reg *agentListenerRegistry) serve(al *agentListener) {
+	for {
+		conn, err := al.listener.Accept()
+		if err != nil {
+			return
+		}
+		go reg.forward(al, conn)
+	}
+}
+
+// forward opens a chanForwardedTransport channel identifying al.addr and
+// pipes conn to it until either side closes.
+func (reg *agentListenerRegistry) forward(al *agentListener, conn net.Conn) {
+	defer conn.Close()
+
+	ch, reqs, err := reg.sshConn.OpenChannel(chanForwardedTransport, ssh.Marshal(&forwardedTransportMsg{Addr: al.addr}))
+	if err != nil {
+		log.Errorf("failed to open forwarded-transport channel for %v: %v", al.addr, trace.Wrap(err))
+		return
+	}
+	defer ch.Close()
+	go ssh.DiscardRequests(reqs)
+
+	pipeConn(ch, conn)
+}
+
+// closeAll closes every listener still registered, called once
+// handleAgentGlobalRequests' request channel closes because the tunnel went
+// down.
+func (reg *agentListenerRegistry) closeAll() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for addr, al := range reg.listeners {
+		al.listener.Close()
+		delete(reg.listeners, addr)
+	}
+}
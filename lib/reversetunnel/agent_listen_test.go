@@ -0,0 +1,132 @@
+/*
+Copyright 2015 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reversetunnel
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// TestTunnelSiteListenEndToEnd exercises tunnelSite.Listen against an
+// in-process tunnel, with ServeAgentConn standing in for the agent process:
+// it asks the agent to bind a Unix listener, dials it directly (as a client
+// of the exposed service would), and verifies the connection is forwarded
+// back over a chanForwardedTransport channel and surfaces from the returned
+// net.Listener's Accept.
+func TestTunnelSiteListenEndToEnd(t *testing.T) {
+	t.Parallel()
+
+	proxySSHConn, proxyChans, proxyNetConn := newTestSSHPipe(t)
+
+	site := &tunnelSite{
+		log: log.WithField("test", "TestTunnelSiteListenEndToEnd"),
+		srv: &server{},
+	}
+	rc, err := newRemoteConn(site.log, proxyNetConn, proxySSHConn)
+	require.NoError(t, err)
+	site.connections = []*remoteConn{rc}
+
+	// route chanForwardedTransport channels the agent opens back, mirroring
+	// (*server).handleForwardedTransport.
+	stubChanForwardedTransportRouter(t, site, proxyChans, proxySSHConn)
+
+	socketPath := filepath.Join(t.TempDir(), "listen.sock")
+
+	listener, err := site.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	acceptDone := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			acceptDone <- conn
+		}
+	}()
+
+	clientConn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer clientConn.Close()
+
+	var forwarded net.Conn
+	select {
+	case forwarded = <-acceptDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Listen()'s Accept to receive the forwarded connection")
+	}
+	defer forwarded.Close()
+
+	const payload = "hello from the exposed listener"
+	_, err = clientConn.Write([]byte(payload))
+	require.NoError(t, err)
+
+	buf := make([]byte, len(payload))
+	_, err = io.ReadFull(forwarded, buf)
+	require.NoError(t, err)
+	require.Equal(t, payload, string(buf))
+}
+
+// stubChanForwardedTransportRouter relays chanForwardedTransport channels
+// opened by the agent to site.getListener, mirroring
+// (*server).handleForwardedTransport without requiring a full server struct.
+func stubChanForwardedTransportRouter(t *testing.T, site *tunnelSite, chans <-chan ssh.NewChannel, sconn *ssh.ServerConn) {
+	t.Helper()
+	go func() {
+		for nch := range chans {
+			if nch.ChannelType() != chanForwardedTransport {
+				nch.Reject(ssh.UnknownChannelType, "unexpected channel type")
+				continue
+			}
+			var msg forwardedTransportMsg
+			if err := ssh.Unmarshal(nch.ExtraData(), &msg); err != nil {
+				nch.Reject(ssh.ConnectionFailed, "bad forwarded-transport request")
+				continue
+			}
+			listener, ok := site.getListener(msg.Addr)
+			if !ok {
+				nch.Reject(ssh.ConnectionFailed, "no such listener")
+				continue
+			}
+			ch, reqs, err := nch.Accept()
+			if err != nil {
+				continue
+			}
+			go ssh.DiscardRequests(reqs)
+			listener.push(chConn{Channel: ch, conn: sconn})
+		}
+	}()
+}
+
+// chConn adapts an ssh.Channel into a net.Conn for test purposes, standing
+// in for utils.NewChConn.
+type chConn struct {
+	ssh.Channel
+	conn ssh.Conn
+}
+
+func (c chConn) LocalAddr() net.Addr                { return c.conn.LocalAddr() }
+func (c chConn) RemoteAddr() net.Addr               { return c.conn.RemoteAddr() }
+func (c chConn) SetDeadline(t time.Time) error      { return nil }
+func (c chConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c chConn) SetWriteDeadline(t time.Time) error { return nil }
@@ -0,0 +1,133 @@
+/*
+Copyright 2015 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reversetunnel
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestSSHPipe wires up an in-process SSH connection over net.Pipe, with
+// one end behaving like the proxy's accepted agent connection (a
+// *ssh.ServerConn, the same type remoteConn.sshConn wraps in production) and
+// the other behaving like the agent's dial-out connection. The agent end is
+// driven by ServeAgentConn, the exported entry point for the agent's side of
+// a tunnel connection, so tests exercise its dispatch rather than calling its
+// handlers directly.
+// It returns once both ends have completed the handshake.
+func newTestSSHPipe(t *testing.T) (proxyConn *ssh.ServerConn, proxyChans <-chan ssh.NewChannel, proxyConnNet net.Conn) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(signer)
+	clientConfig := &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	proxySide, agentSide := net.Pipe()
+
+	type serverResult struct {
+		conn     *ssh.ServerConn
+		newChans <-chan ssh.NewChannel
+		reqs     <-chan *ssh.Request
+		err      error
+	}
+	serverDone := make(chan serverResult, 1)
+	go func() {
+		conn, newChans, reqs, err := ssh.NewServerConn(proxySide, serverConfig)
+		serverDone <- serverResult{conn, newChans, reqs, err}
+	}()
+
+	agentConn, agentNewChans, agentReqC, err := ssh.NewClientConn(agentSide, "agent", clientConfig)
+	require.NoError(t, err)
+	go ServeAgentConn(agentConn, agentNewChans, agentReqC)
+
+	res := <-serverDone
+	require.NoError(t, res.err)
+	go ssh.DiscardRequests(res.reqs)
+
+	t.Cleanup(func() {
+		agentConn.Close()
+		res.conn.Close()
+	})
+
+	return res.conn, res.newChans, proxySide
+}
+
+// TestTryDialUnixEndToEnd exercises a Unix listener on the "remote" (agent)
+// side via an in-process tunnel: it spins up a real Unix socket, asks
+// tryDialUnix to reach it over the SSH connection, and verifies bytes
+// written on the proxy's returned net.Conn are observed by the listener and
+// vice versa. This is the path tunnelSite.Dial("unix", ...) relies on, with
+// ServeAgentConn standing in for the agent process.
+func TestTryDialUnixEndToEnd(t *testing.T) {
+	t.Parallel()
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	target, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer target.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := target.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	proxySSHConn, _, proxyNetConn := newTestSSHPipe(t)
+
+	site := &tunnelSite{}
+	rc, err := newRemoteConn(log.WithField("test", "TestTryDialUnixEndToEnd"), proxyNetConn, proxySSHConn)
+	require.NoError(t, err)
+
+	clientConn, err := site.tryDialUnix(rc, socketPath)
+	require.NoError(t, err)
+	defer clientConn.Close()
+
+	var serverConn net.Conn
+	select {
+	case serverConn = <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for unix listener to accept")
+	}
+	defer serverConn.Close()
+
+	const payload = "hello over the tunnel"
+	_, err = clientConn.Write([]byte(payload))
+	require.NoError(t, err)
+
+	buf := make([]byte, len(payload))
+	_, err = io.ReadFull(serverConn, buf)
+	require.NoError(t, err)
+	require.Equal(t, payload, string(buf))
+}
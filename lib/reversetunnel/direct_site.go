@@ -0,0 +1,120 @@
+/*
+Copyright 2015 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reversetunnel
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/trace"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// directSite is a RemoteSite that accesses a cluster directly over the
+// network instead of through a reverse tunnel, used when the proxy can
+// already reach the cluster's servers and auth server on its own, e.g.
+// because they're part of the same teleport deployment.
+type directSite struct {
+	domainName string
+	connClt    auth.ClientI
+}
+
+// newDirectSite returns a RemoteSite for domainName that dials out directly
+// rather than through a tunnel, using clt to talk to the cluster's auth
+// server.
+func newDirectSite(domainName string, clt auth.ClientI) *directSite {
+	return &directSite{
+		domainName: domainName,
+		connClt:    clt,
+	}
+}
+
+func (s *directSite) ConnectToServer(addr, user string, auth []ssh.AuthMethod) (*ssh.Client, error) {
+	return s.ConnectToServerContext(context.Background(), addr, user, auth)
+}
+
+// ConnectToServerContext dials addr directly and establishes an SSH
+// connection over it, rather than tunneling a channel open through an agent.
+func (s *directSite) ConnectToServerContext(ctx context.Context, addr, user string, authMethods []ssh.AuthMethod) (*ssh.Client, error) {
+	conn, err := s.DialServerContext(ctx, addr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	client, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User: user,
+		Auth: authMethods,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return ssh.NewClient(client, chans, reqs), nil
+}
+
+func (s *directSite) DialServer(addr string) (net.Conn, error) {
+	return s.DialServerContext(context.Background(), addr)
+}
+
+func (s *directSite) DialServerContext(ctx context.Context, addr string) (net.Conn, error) {
+	return s.DialContext(ctx, "tcp", addr)
+}
+
+func (s *directSite) Dial(network, addr string) (net.Conn, error) {
+	return s.DialContext(context.Background(), network, addr)
+}
+
+// DialContext dials network/addr directly; a direct site has no tunnel to
+// route the dial through.
+func (s *directSite) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := net.Dialer{}
+	return d.DialContext(ctx, network, addr)
+}
+
+// Listen always fails for a direct site: reverse port forwarding exists to
+// expose a port from a cluster that can only dial out, which doesn't apply
+// to a cluster the proxy can already reach directly.
+func (s *directSite) Listen(network, addr string) (net.Listener, error) {
+	return nil, trace.BadParameter("reverse listen is not supported for a direct site")
+}
+
+// ConnectionStats returns no stats for a direct site, which has no tunnel
+// connections to report on.
+func (s *directSite) ConnectionStats() []ConnectionStats {
+	return nil
+}
+
+func (s *directSite) GetLastConnected() time.Time {
+	return time.Now()
+}
+
+func (s *directSite) GetName() string {
+	return s.domainName
+}
+
+func (s *directSite) GetStatus() string {
+	return RemoteSiteStatusOnline
+}
+
+func (s *directSite) GetClient() (auth.ClientI, error) {
+	return s.connClt, nil
+}
+
+func (s *directSite) GetHangoutInfo() (*HangoutSiteInfo, error) {
+	return nil, trace.Errorf("No hangout info")
+}
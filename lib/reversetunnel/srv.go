@@ -17,7 +17,10 @@ limitations under the License.
 package reversetunnel
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
 	"net/http"
 	"strings"
@@ -45,10 +48,34 @@ import (
 type RemoteSite interface {
 	// ConnectToServer allows to SSH into remote teleport server
 	ConnectToServer(addr, user string, auth []ssh.AuthMethod) (*ssh.Client, error)
+	// ConnectToServerContext is the context-aware variant of
+	// ConnectToServer. It fails with ctx's error if ctx is already done, and
+	// a deadline carried by ctx bounds how long it waits. It makes a single
+	// attempt and does not retry.
+	ConnectToServerContext(ctx context.Context, addr, user string, auth []ssh.AuthMethod) (*ssh.Client, error)
 	// DialServer dials teleport server and returns connection
 	DialServer(addr string) (net.Conn, error)
-	// Dial dials any address withing reach of remote site's servers
+	// DialServerContext is the context-aware variant of DialServer.
+	DialServerContext(ctx context.Context, addr string) (net.Conn, error)
+	// Dial dials any address withing reach of remote site's servers. network
+	// is either "tcp" or "unix"; for "unix" addr is a socket path on the
+	// remote site's servers, tunneled using the same mechanism OpenSSH uses
+	// for its direct-streamlocal@openssh.com channels.
 	Dial(network, addr string) (net.Conn, error)
+	// DialContext is the context-aware variant of Dial. It aborts and
+	// returns ctx.Err() once ctx is done, instead of retrying forever on
+	// transient errors. This lets callers that already carry a request
+	// context (e.g. handleAuthProxy) stop waiting without leaking a
+	// goroutine once the client disconnects.
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	// Listen asks the remote site to bind a listener on network/addr on its
+	// own side and tunnel back any connections it accepts, the mirror image
+	// of Dial. It's used for reverse port forwarding, e.g. exposing a port
+	// on the proxy from inside a cluster that only ever dials out.
+	Listen(network, addr string) (net.Listener, error)
+	// ConnectionStats returns a snapshot of load and health stats for each
+	// of this site's tunnel connections, for observability.
+	ConnectionStats() []ConnectionStats
 	// GetLastConnected returns last time the remote site was seen connected
 	GetLastConnected() time.Time
 	// GetName returns site name (identified by authority domain's name)
@@ -92,6 +119,9 @@ type server struct {
 	srv             *sshutils.Server
 	timeout         time.Duration
 
+	keepAliveInterval time.Duration
+	keepAliveCountMax int
+
 	tunnelSites []*tunnelSite
 	directSites []*directSite
 }
@@ -106,6 +136,22 @@ func ServerTimeout(duration time.Duration) ServerOption {
 	}
 }
 
+// KeepAliveInterval sets how often the proxy sends a keepalive@openssh.com
+// request over each remoteConn to detect a dead tunnel.
+func KeepAliveInterval(interval time.Duration) ServerOption {
+	return func(s *server) {
+		s.keepAliveInterval = interval
+	}
+}
+
+// KeepAliveCountMax sets after how many consecutive missed keepalives a
+// remoteConn is marked invalid and evicted.
+func KeepAliveCountMax(count int) ServerOption {
+	return func(s *server) {
+		s.keepAliveCountMax = count
+	}
+}
+
 // DirectSite instructs server to proxy access to this site not using
 // reverse tunnel
 func DirectSite(domainName string, clt auth.ClientI) ServerOption {
@@ -129,6 +175,12 @@ func NewServer(addr utils.NetAddr, hostSigners []ssh.Signer,
 	if srv.timeout == 0 {
 		srv.timeout = teleport.DefaultServerTimeout
 	}
+	if srv.keepAliveInterval == 0 {
+		srv.keepAliveInterval = defaultKeepAliveInterval
+	}
+	if srv.keepAliveCountMax == 0 {
+		srv.keepAliveCountMax = defaultKeepAliveCountMax
+	}
 
 	s, err := sshutils.NewServer(
 		addr,
@@ -196,7 +248,53 @@ func (s *server) HandleNewChan(conn net.Conn, sconn *ssh.ServerConn, nch ssh.New
 			return
 		}
 		go site.handleHeartbeat(ch, req)
+	case chanForwardedTransport:
+		s.handleForwardedTransport(sconn, nch)
+	}
+}
+
+// handleForwardedTransport accepts a chanForwardedTransport channel opened
+// by an agent to hand back a connection accepted on one of its site's
+// Listen-requested listeners, and delivers it to that listener's Accept
+// queue.
+func (s *server) handleForwardedTransport(sconn *ssh.ServerConn, nch ssh.NewChannel) {
+	var msg forwardedTransportMsg
+	if err := ssh.Unmarshal(nch.ExtraData(), &msg); err != nil {
+		log.Errorf("failed to unmarshal forwarded-transport request: %v", err)
+		nch.Reject(ssh.ConnectionFailed, "bad forwarded-transport request")
+		return
+	}
+
+	domainName := sconn.Permissions.Extensions[extAuthority]
+	s.RLock()
+	var site *tunnelSite
+	for _, st := range s.tunnelSites {
+		if st.domainName == domainName {
+			site = st
+			break
+		}
+	}
+	s.RUnlock()
+	if site == nil {
+		log.Errorf("forwarded-transport channel for unknown site %v", domainName)
+		nch.Reject(ssh.ConnectionFailed, "unknown site")
+		return
+	}
+
+	listener, ok := site.getListener(msg.Addr)
+	if !ok {
+		log.Errorf("forwarded-transport channel for unregistered listener %v", msg.Addr)
+		nch.Reject(ssh.ConnectionFailed, "no such listener")
+		return
+	}
+
+	ch, reqs, err := nch.Accept()
+	if err != nil {
+		log.Errorf("failed to accept forwarded-transport channel: %v", err)
+		return
 	}
+	go ssh.DiscardRequests(reqs)
+	listener.push(utils.NewChConn(sconn, ch))
 }
 
 // isHostAuthority is called during checking the client key, to see if the signing
@@ -524,11 +622,57 @@ type remoteConn struct {
 	invalid int32
 	log     *log.Entry
 	counter int32
+
+	stopOnce sync.Once
+	stopC    chan struct{}
+
+	// statsMu guards the fields below, which nextConn's power-of-two-choices
+	// scheduler reads and updates to favor lightly loaded, healthy
+	// connections over a connection that is already busy or erroring.
+	statsMu       sync.Mutex
+	inFlight      int
+	ewmaLatency   time.Duration
+	errorCount    int
+	lastKeepAlive time.Time
+}
+
+// ConnectionStats is a point-in-time snapshot of a single tunnel
+// connection's load and health, as tracked by nextConn's scheduler.
+type ConnectionStats struct {
+	// RemoteAddr is the address of the agent on the other end of the
+	// connection.
+	RemoteAddr string
+	// InFlight is the number of dial/channel-open requests currently
+	// outstanding on this connection.
+	InFlight int
+	// AvgLatency is an EWMA of recent channel-open latency.
+	AvgLatency time.Duration
+	// ErrorCount is the number of recent dial/channel-open failures that
+	// haven't yet been offset by a success.
+	ErrorCount int
+	// LastKeepAlive is the time of the last successful proxy-driven
+	// keepalive, or the zero time if none has succeeded yet.
+	LastKeepAlive time.Time
+	// Invalid is true once the connection has been pruned from rotation.
+	Invalid bool
 }
 
 func (rc *remoteConn) setDeadline(d time.Duration) {
+	rc.setDeadlineContext(context.Background(), d)
+}
+
+// setDeadlineContext behaves like setDeadline, except that if ctx carries a
+// deadline earlier than now+d, that earlier deadline is used instead. This
+// lets a ctx's deadline unblock the channel open/request it guards without
+// waiting for the full timeout. Cancelling a ctx that has no deadline does
+// not affect the deadline set here.
+func (rc *remoteConn) setDeadlineContext(ctx context.Context, d time.Duration) {
 	atomic.AddInt32(&rc.counter, 1)
-	rc.conn.SetDeadline(time.Now().Add(d))
+	deadline := time.Now().Add(d)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	rc.conn.SetDeadline(deadline)
 }
 
 func (rc *remoteConn) resetDeadline() {
@@ -545,7 +689,14 @@ func (rc *remoteConn) resetDeadline() {
 	}
 }
 
+// stop terminates this connection's keepalive loop. It is safe to call more
+// than once.
+func (rc *remoteConn) stop() {
+	rc.stopOnce.Do(func() { close(rc.stopC) })
+}
+
 func (rc *remoteConn) Close() error {
+	rc.stop()
 	return rc.sshConn.Close()
 }
 
@@ -558,14 +709,151 @@ func (rc *remoteConn) isInvalid() bool {
 	return atomic.LoadInt32(&rc.invalid) == 1
 }
 
+// latencyEWMAWeight is the weight given to each new sample when updating
+// rc.ewmaLatency; lower values smooth out transient spikes more.
+const latencyEWMAWeight = 0.2
+
+// recordDialStart marks the start of a dial or channel-open attempt on rc
+// and returns a function the caller defers, passing the attempt's outcome,
+// to update rc's in-flight count, error count, and latency EWMA.
+func (rc *remoteConn) recordDialStart() func(err error) {
+	start := time.Now()
+	rc.statsMu.Lock()
+	rc.inFlight++
+	rc.statsMu.Unlock()
+	return func(err error) {
+		elapsed := time.Since(start)
+		rc.statsMu.Lock()
+		defer rc.statsMu.Unlock()
+		rc.inFlight--
+		if err != nil {
+			rc.errorCount++
+			return
+		}
+		if rc.errorCount > 0 {
+			rc.errorCount--
+		}
+		if rc.ewmaLatency == 0 {
+			rc.ewmaLatency = elapsed
+		} else {
+			rc.ewmaLatency = time.Duration((1-latencyEWMAWeight)*float64(rc.ewmaLatency) + latencyEWMAWeight*float64(elapsed))
+		}
+	}
+}
+
+// recordKeepAliveSuccess notes that a proxy-driven keepalive on rc just
+// succeeded, for ConnectionStats.
+func (rc *remoteConn) recordKeepAliveSuccess() {
+	rc.statsMu.Lock()
+	rc.lastKeepAlive = time.Now()
+	rc.statsMu.Unlock()
+}
+
+// passedKeepAlive reports whether rc has ever had a proxy-driven keepalive
+// succeed. GetStatus uses this, rather than isInvalid alone, so a
+// brand-new connection that hasn't completed its first keepalive round
+// trip isn't reported online before it's actually proven healthy.
+func (rc *remoteConn) passedKeepAlive() bool {
+	rc.statsMu.Lock()
+	defer rc.statsMu.Unlock()
+	return !rc.lastKeepAlive.IsZero()
+}
+
+// score returns a lower-is-better load/health score for rc, combining
+// in-flight requests, recent errors, and latency. nextConn uses it to pick
+// the better of two randomly sampled connections ("power of two choices").
+func (rc *remoteConn) score() float64 {
+	rc.statsMu.Lock()
+	defer rc.statsMu.Unlock()
+	return float64(rc.inFlight) + float64(rc.errorCount)*5 + rc.ewmaLatency.Seconds()
+}
+
+// stats returns a snapshot of rc's current load and health.
+func (rc *remoteConn) stats() ConnectionStats {
+	rc.statsMu.Lock()
+	defer rc.statsMu.Unlock()
+	return ConnectionStats{
+		RemoteAddr:    rc.conn.RemoteAddr().String(),
+		InFlight:      rc.inFlight,
+		AvgLatency:    rc.ewmaLatency,
+		ErrorCount:    rc.errorCount,
+		LastKeepAlive: rc.lastKeepAlive,
+		Invalid:       rc.isInvalid(),
+	}
+}
+
 func newRemoteConn(log *log.Entry, conn net.Conn, sshConn ssh.Conn) (*remoteConn, error) {
 	return &remoteConn{
 		sshConn: sshConn,
 		conn:    conn,
 		log:     log,
+		stopC:   make(chan struct{}),
 	}, nil
 }
 
+// keepAliveLoop periodically sends a keepalive@openssh.com request over rc
+// and, once countMax consecutive requests have failed or timed out, marks rc
+// invalid and closes its underlying net.Conn so nextConn prunes it
+// immediately instead of waiting to stumble on it lazily.
+func (rc *remoteConn) keepAliveLoop(interval time.Duration, countMax int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-rc.stopC:
+			return
+		case <-ticker.C:
+			ok, err := rc.sendKeepAlive(interval)
+			if err != nil || !ok {
+				missed++
+				rc.log.Debugf("missed keepalive %v/%v for %v", missed, countMax, rc.conn.RemoteAddr())
+				if missed >= countMax {
+					rc.log.Infof("%v missed %v consecutive keepalives, closing", rc.conn.RemoteAddr(), missed)
+					rc.markInvalid()
+					rc.conn.Close()
+					return
+				}
+				continue
+			}
+			missed = 0
+			rc.recordKeepAliveSuccess()
+		}
+	}
+}
+
+// keepAliveResult is the outcome of a keepalive@openssh.com request sent by
+// sendKeepAlive.
+type keepAliveResult struct {
+	ok  bool
+	err error
+}
+
+// sendKeepAlive sends a keepalive@openssh.com request over rc and waits up
+// to timeout for a reply. Unlike the setDeadline/resetDeadline pair
+// ConnectToServerContext/tryDialContext use, it doesn't touch
+// rc.conn's deadline: that deadline is a single absolute value shared with
+// whatever foreground dial is in flight on rc, so a keepalive tick firing
+// mid-dial would otherwise shrink that dial's effective timeout down to
+// interval. If timeout elapses before SendRequest returns, sendKeepAlive
+// reports it as a miss and abandons the in-flight request rather than
+// waiting for it; a SendRequest left running past that point will still
+// complete (or fail) on its own once the connection is closed or replies.
+func (rc *remoteConn) sendKeepAlive(timeout time.Duration) (bool, error) {
+	resultC := make(chan keepAliveResult, 1)
+	go func() {
+		ok, _, err := rc.sshConn.SendRequest(keepAliveRequest, true, nil)
+		resultC <- keepAliveResult{ok: ok, err: err}
+	}()
+	select {
+	case res := <-resultC:
+		return res.ok, res.err
+	case <-time.After(timeout):
+		return false, trace.Errorf("timed out waiting for keepalive reply")
+	}
+}
+
 func newRemoteSite(srv *server, domainName string) (*tunnelSite, error) {
 	remoteSite := &tunnelSite{
 		srv:        srv,
@@ -602,7 +890,6 @@ type tunnelSite struct {
 	log         *log.Entry
 	domainName  string
 	connections []*remoteConn
-	lastUsed    int
 	lastActive  time.Time
 	srv         *server
 
@@ -610,6 +897,10 @@ type tunnelSite struct {
 	clt       *auth.Client
 
 	hangoutInfo *HangoutSiteInfo
+
+	// listeners holds the tunnelListener for every address currently
+	// Listen()-ing on this site, keyed by addr.
+	listeners map[string]*tunnelListener
 }
 
 func (s *tunnelSite) GetClient() (auth.ClientI, error) {
@@ -620,26 +911,45 @@ func (s *tunnelSite) String() string {
 	return fmt.Sprintf("remoteSite(%v)", s.domainName)
 }
 
+// nextConn prunes any connections keepAliveLoop has marked invalid, then
+// picks between two randomly sampled connections using "power of two
+// choices": each holds a score combining in-flight requests, recent
+// errors, and latency, and the lower-scoring (less loaded, healthier) one
+// is returned. This avoids the way a plain round-robin can pin retries to
+// a single degraded connection or starve a healthy one.
 func (s *tunnelSite) nextConn() (*remoteConn, error) {
 	s.Lock()
 	defer s.Unlock()
 
-	for {
-		if len(s.connections) == 0 {
-			return nil, trace.Wrap(
-				&teleport.NotFoundError{
-					Message: "no active connections"})
-		}
-		s.lastUsed = (s.lastUsed + 1) % len(s.connections)
-		remoteConn := s.connections[s.lastUsed]
-		if !remoteConn.isInvalid() {
-			s.log.Infof("return connection %v", s.lastUsed)
-			return remoteConn, nil
+	s.pruneInvalidConns()
+	if len(s.connections) == 0 {
+		return nil, trace.Wrap(
+			&teleport.NotFoundError{
+				Message: "no active connections"})
+	}
+	if len(s.connections) == 1 {
+		return s.connections[0], nil
+	}
+	a := s.connections[rand.Intn(len(s.connections))]
+	b := s.connections[rand.Intn(len(s.connections))]
+	if a.score() <= b.score() {
+		return a, nil
+	}
+	return b, nil
+}
+
+// pruneInvalidConns removes and closes connections keepAliveLoop has
+// marked invalid. Callers must hold s.Lock().
+func (s *tunnelSite) pruneInvalidConns() {
+	live := s.connections[:0]
+	for _, conn := range s.connections {
+		if conn.isInvalid() {
+			go conn.Close()
+			continue
 		}
-		s.connections = append(s.connections[:s.lastUsed], s.connections[s.lastUsed+1:]...)
-		s.lastUsed = 0
-		go remoteConn.Close()
+		live = append(live, conn)
 	}
+	s.connections = live
 }
 
 func (s *tunnelSite) addConn(conn net.Conn, sshConn ssh.Conn) error {
@@ -650,16 +960,41 @@ func (s *tunnelSite) addConn(conn net.Conn, sshConn ssh.Conn) error {
 	s.Lock()
 	defer s.Unlock()
 	s.connections = append(s.connections, remoteConn)
-	s.lastUsed = 0
+	go remoteConn.keepAliveLoop(s.srv.keepAliveInterval, s.srv.keepAliveCountMax)
 	return nil
 }
 
+// ConnectionStats returns a snapshot of load and health stats for each of
+// this site's tunnel connections, for observability.
+func (s *tunnelSite) ConnectionStats() []ConnectionStats {
+	s.Lock()
+	defer s.Unlock()
+	stats := make([]ConnectionStats, 0, len(s.connections))
+	for _, conn := range s.connections {
+		stats = append(stats, conn.stats())
+	}
+	return stats
+}
+
+// GetStatus reports the site as online iff at least one of its connections
+// has had a proxy-driven keepalive succeed at some point and has not been
+// marked invalid, rather than relying solely on the agent's own heartbeat,
+// so a dead tunnel is reflected as soon as keepAliveLoop evicts it instead
+// of up to 2*heartbeatPeriod later. A connection whose most recent
+// keepalives failed, but fewer than KeepAliveCountMax of them in a row,
+// still counts as online. One consequence: a brand-new
+// connection reports Offline until its first keepalive round trip succeeds
+// (up to KeepAliveInterval after connecting), whereas previously a freshly
+// added connection was considered Online immediately.
 func (s *tunnelSite) GetStatus() string {
-	diff := time.Now().Sub(s.lastActive)
-	if diff > 2*heartbeatPeriod {
-		return RemoteSiteStatusOffline
+	s.Lock()
+	defer s.Unlock()
+	for _, conn := range s.connections {
+		if !conn.isInvalid() && conn.passedKeepAlive() {
+			return RemoteSiteStatusOnline
+		}
 	}
-	return RemoteSiteStatusOnline
+	return RemoteSiteStatusOffline
 }
 
 func (s *tunnelSite) handleHeartbeat(ch ssh.Channel, reqC <-chan *ssh.Request) {
@@ -689,26 +1024,40 @@ func (s *tunnelSite) timeout() time.Duration {
 }
 
 func (s *tunnelSite) ConnectToServer(server, user string, auth []ssh.AuthMethod) (*ssh.Client, error) {
+	return s.ConnectToServerContext(context.Background(), server, user, auth)
+}
+
+func (s *tunnelSite) ConnectToServerContext(ctx context.Context, server, user string, auth []ssh.AuthMethod) (*ssh.Client, error) {
 	s.log.Infof("ConnectToServer(server=%v, user=%v)", server, user)
+	select {
+	case <-ctx.Done():
+		return nil, trace.Wrap(ctx.Err())
+	default:
+	}
 	remoteConn, err := s.nextConn()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	remoteConn.setDeadline(s.timeout())
+	remoteConn.setDeadlineContext(ctx, s.timeout())
 	defer remoteConn.resetDeadline()
+	done := remoteConn.recordDialStart()
 	ch, _, err := remoteConn.sshConn.OpenChannel(chanTransport, nil)
 	if err != nil {
 		remoteConn.markInvalid()
+		done(err)
 		return nil, trace.Wrap(err)
 	}
 	// ask remote channel to dial
 	dialed, err := ch.SendRequest(chanTransportDialReq, true, []byte(server))
 	if err != nil {
 		remoteConn.markInvalid()
+		done(err)
 		return nil, trace.Wrap(err)
 	}
 	if !dialed {
-		return nil, trace.Errorf("remote server %v is not available", server)
+		err := trace.Errorf("remote server %v is not available", server)
+		done(err)
+		return nil, err
 	}
 	transportConn := utils.NewChConn(remoteConn.sshConn, ch)
 	conn, chans, reqs, err := ssh.NewClientConn(
@@ -717,6 +1066,7 @@ func (s *tunnelSite) ConnectToServer(server, user string, auth []ssh.AuthMethod)
 			User: user,
 			Auth: auth,
 		})
+	done(err)
 	if err != nil {
 		s.log.Infof("connectToServer %v", err)
 		return nil, trace.Wrap(err)
@@ -733,7 +1083,9 @@ func (s *tunnelSite) tryDialAccessPoint(network, addr string) (net.Conn, error)
 	remoteConn.setDeadline(s.timeout())
 	defer remoteConn.resetDeadline()
 
+	done := remoteConn.recordDialStart()
 	ch, _, err := remoteConn.sshConn.OpenChannel(chanAccessPoint, nil)
+	done(err)
 	if err != nil {
 		remoteConn.markInvalid()
 		s.log.Infof("%v marking connection invalid, conn err: %v", remoteConn.conn.RemoteAddr(), err)
@@ -757,18 +1109,25 @@ func (s *tunnelSite) dialAccessPoint(network, addr string) (net.Conn, error) {
 	}
 }
 
-func (s *tunnelSite) tryDial(net, addr string) (net.Conn, error) {
-	s.log.Infof("tryDial(net=%v, addr=%v)", net, addr)
+func (s *tunnelSite) tryDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	s.log.Infof("tryDial(net=%v, addr=%v)", network, addr)
 	remoteConn, err := s.nextConn()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	remoteConn.setDeadline(s.timeout())
+	remoteConn.setDeadlineContext(ctx, s.timeout())
 	defer remoteConn.resetDeadline()
+
+	if network == "unix" {
+		return s.tryDialUnix(remoteConn, addr)
+	}
+
+	done := remoteConn.recordDialStart()
 	var ch ssh.Channel
 	ch, _, err = remoteConn.sshConn.OpenChannel(chanTransport, nil)
 	if err != nil {
 		remoteConn.markInvalid()
+		done(err)
 		return nil, trace.Wrap(err)
 	}
 	// ask remote channel to dial
@@ -776,21 +1135,64 @@ func (s *tunnelSite) tryDial(net, addr string) (net.Conn, error) {
 	dialed, err = ch.SendRequest(chanTransportDialReq, true, []byte(addr))
 	if err != nil {
 		remoteConn.markInvalid()
+		done(err)
 		return nil, trace.Wrap(err)
 	}
 	if !dialed {
 		remoteConn.markInvalid()
-		return nil, trace.Wrap(
-			teleport.ConnectionProblem(
-				fmt.Sprintf("remote server %v is not available", addr), nil))
+		err := teleport.ConnectionProblem(
+			fmt.Sprintf("remote server %v is not available", addr), nil)
+		done(err)
+		return nil, trace.Wrap(err)
 	}
+	done(nil)
 	return utils.NewChConn(remoteConn.sshConn, ch), nil
 }
 
-func (s *tunnelSite) Dial(net string, addr string) (net.Conn, error) {
-	s.log.Infof("Dial(net=%v, addr=%v)", net, addr)
+// streamLocalChannelOpenDirectMsg is the extra data carried by a
+// direct-streamlocal@openssh.com channel open request: the path of the
+// remote Unix socket to dial, followed by two fields OpenSSH reserves for
+// future use. See golang.org/x/crypto/ssh/streamlocal.go for the same
+// layout on the client side of a regular SSH connection.
+type streamLocalChannelOpenDirectMsg struct {
+	SocketPath string
+	Reserved0  string
+	Reserved1  uint32
+}
+
+// chanDirectStreamLocal is the SSH channel type used to tunnel a dial to a
+// Unix domain socket on the remote site, mirroring OpenSSH's
+// direct-streamlocal@openssh.com.
+const chanDirectStreamLocal = "direct-streamlocal@openssh.com"
+
+// tryDialUnix dials socketPath on the agent side of remoteConn using a
+// direct-streamlocal@openssh.com channel, so that sockets such as
+// /var/run/docker.sock can be reached without exposing them over TCP.
+func (s *tunnelSite) tryDialUnix(remoteConn *remoteConn, socketPath string) (net.Conn, error) {
+	done := remoteConn.recordDialStart()
+	msg := streamLocalChannelOpenDirectMsg{SocketPath: socketPath}
+	ch, _, err := remoteConn.sshConn.OpenChannel(chanDirectStreamLocal, ssh.Marshal(&msg))
+	done(err)
+	if err != nil {
+		remoteConn.markInvalid()
+		return nil, trace.Wrap(err)
+	}
+	return utils.NewChConn(remoteConn.sshConn, ch), nil
+}
+
+func (s *tunnelSite) Dial(network string, addr string) (net.Conn, error) {
+	return s.DialContext(context.Background(), network, addr)
+}
+
+func (s *tunnelSite) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	s.log.Infof("Dial(net=%v, addr=%v)", network, addr)
 	for {
-		conn, err := s.tryDial(net, addr)
+		select {
+		case <-ctx.Done():
+			return nil, trace.Wrap(ctx.Err())
+		default:
+		}
+		conn, err := s.tryDialContext(ctx, network, addr)
 		if err != nil {
 			s.log.Infof("got error: %v", err)
 			// we interpret it as a "out of connections and will try again"
@@ -804,6 +1206,10 @@ func (s *tunnelSite) Dial(net string, addr string) (net.Conn, error) {
 }
 
 func (s *tunnelSite) DialServer(addr string) (net.Conn, error) {
+	return s.DialServerContext(context.Background(), addr)
+}
+
+func (s *tunnelSite) DialServerContext(ctx context.Context, addr string) (net.Conn, error) {
 	s.log.Infof("DialServer(addr=%v)", addr)
 	clt, err := s.GetClient()
 	if err != nil {
@@ -817,7 +1223,171 @@ func (s *tunnelSite) DialServer(addr string) (net.Conn, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return s.Dial("tcp", server.Addr)
+	return s.DialContext(ctx, "tcp", server.Addr)
+}
+
+// chanTransportListen is the SSH global request a proxy sends over a
+// remoteConn's sshConn to ask the agent to bind network/addr on its side and
+// tunnel back any connections it accepts as chanForwardedTransport channels.
+const chanTransportListen = "listen@teleport"
+
+// chanTransportUnlisten is the SSH global request a proxy sends to ask the
+// agent to unbind a listener previously requested with chanTransportListen,
+// the mirror image of chanTransportListen sent from tunnelListener.Close.
+const chanTransportUnlisten = "unlisten@teleport"
+
+// chanForwardedTransport is the SSH channel type an agent uses to hand back
+// a connection accepted on a listener previously requested with
+// chanTransportListen, the mirror image of chanTransport.
+const chanForwardedTransport = "forwarded-transport@teleport"
+
+// listenRequestMsg is the payload of a chanTransportListen request.
+type listenRequestMsg struct {
+	Network string
+	Addr    string
+}
+
+// forwardedTransportMsg is the extra data carried by a
+// chanForwardedTransport channel open, identifying which listener the
+// connection belongs to.
+type forwardedTransportMsg struct {
+	Addr string
+}
+
+// tunnelAddr implements net.Addr for a tunnelListener.
+type tunnelAddr struct {
+	network string
+	addr    string
+}
+
+func (a tunnelAddr) Network() string { return a.network }
+func (a tunnelAddr) String() string  { return a.addr }
+
+// tunnelListener is the net.Listener returned by tunnelSite.Listen.
+// Connections accepted by the agent are delivered by the server's
+// chanForwardedTransport handler as they come in.
+type tunnelListener struct {
+	site       *tunnelSite
+	remoteConn *remoteConn
+	addr       tunnelAddr
+
+	connC  chan net.Conn
+	closeC chan struct{}
+
+	closeOnce sync.Once
+}
+
+func (l *tunnelListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.connC:
+		return conn, nil
+	case <-l.closeC:
+		return nil, trace.Wrap(io.EOF)
+	}
+}
+
+// Close stops delivering connections to l, de-registers it from its site
+// so a later Listen on the same addr can succeed, and asks the agent to
+// unbind the remote listener so it doesn't keep forwarding to a dead
+// tunnelListener.
+func (l *tunnelListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closeC)
+		l.site.unregisterListener(l.addr.addr, l)
+		_, _, err := l.remoteConn.sshConn.SendRequest(
+			chanTransportUnlisten, false, ssh.Marshal(&listenRequestMsg{Network: l.addr.network, Addr: l.addr.addr}))
+		if err != nil {
+			l.site.log.Debugf("failed to unlisten %v/%v on agent: %v", l.addr.network, l.addr.addr, err)
+		}
+	})
+	return nil
+}
+
+func (l *tunnelListener) Addr() net.Addr {
+	return l.addr
+}
+
+// push hands conn to a pending Accept call, or closes conn if the listener
+// has already been closed.
+func (l *tunnelListener) push(conn net.Conn) {
+	select {
+	case l.connC <- conn:
+	case <-l.closeC:
+		conn.Close()
+	}
+}
+
+// Listen asks the agent on the other end of a connection to this site to
+// bind network/addr and tunnel back any connections it accepts. Unlike Dial,
+// which picks a fresh remoteConn per call, the request is bound to a single
+// remoteConn for the life of the listener.
+func (s *tunnelSite) Listen(network, addr string) (net.Listener, error) {
+	s.log.Infof("Listen(net=%v, addr=%v)", network, addr)
+
+	s.Lock()
+	if s.listeners == nil {
+		s.listeners = make(map[string]*tunnelListener)
+	}
+	if _, exists := s.listeners[addr]; exists {
+		s.Unlock()
+		return nil, trace.AlreadyExists("already listening on %v/%v", network, addr)
+	}
+	s.Unlock()
+
+	remoteConn, err := s.nextConn()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	ok, _, err := remoteConn.sshConn.SendRequest(
+		chanTransportListen, true, ssh.Marshal(&listenRequestMsg{Network: network, Addr: addr}))
+	if err != nil {
+		remoteConn.markInvalid()
+		return nil, trace.Wrap(err)
+	}
+	if !ok {
+		return nil, trace.Errorf("agent refused to listen on %v/%v", network, addr)
+	}
+
+	listener := &tunnelListener{
+		site:       s,
+		remoteConn: remoteConn,
+		addr:       tunnelAddr{network: network, addr: addr},
+		connC:      make(chan net.Conn),
+		closeC:     make(chan struct{}),
+	}
+
+	s.Lock()
+	if _, exists := s.listeners[addr]; exists {
+		s.Unlock()
+		remoteConn.sshConn.SendRequest(
+			chanTransportUnlisten, false, ssh.Marshal(&listenRequestMsg{Network: network, Addr: addr}))
+		return nil, trace.AlreadyExists("already listening on %v/%v", network, addr)
+	}
+	s.listeners[addr] = listener
+	s.Unlock()
+
+	return listener, nil
+}
+
+// getListener returns the tunnelListener previously registered for addr by
+// Listen, if any.
+func (s *tunnelSite) getListener(addr string) (*tunnelListener, bool) {
+	s.Lock()
+	defer s.Unlock()
+	listener, ok := s.listeners[addr]
+	return listener, ok
+}
+
+// unregisterListener removes l from s.listeners, but only if it's still the
+// listener registered for addr — a later Listen call for the same addr may
+// already have replaced it by the time Close runs.
+func (s *tunnelSite) unregisterListener(addr string, l *tunnelListener) {
+	s.Lock()
+	defer s.Unlock()
+	if s.listeners[addr] == l {
+		delete(s.listeners, addr)
+	}
 }
 
 func (s *tunnelSite) handleAuthProxy(w http.ResponseWriter, r *http.Request) {
@@ -847,3 +1417,17 @@ const (
 	extCertTypeHost = "host"
 	extCertTypeUser = "user"
 )
+
+const (
+	// defaultKeepAliveInterval is how often the proxy pings an agent over a
+	// remoteConn when the caller did not set KeepAliveInterval.
+	defaultKeepAliveInterval = 15 * time.Second
+	// defaultKeepAliveCountMax is after how many consecutive missed
+	// keepalives a remoteConn is evicted when the caller did not set
+	// KeepAliveCountMax.
+	defaultKeepAliveCountMax = 3
+)
+
+// keepAliveRequest is the SSH global/channel request name OpenSSH uses for
+// its own keepalive pings.
+const keepAliveRequest = "keepalive@openssh.com"
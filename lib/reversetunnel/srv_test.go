@@ -0,0 +1,284 @@
+/*
+Copyright 2015 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reversetunnel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	errTestOpenChannelNotImplemented = errors.New("fakeSSHConn: OpenChannel not implemented")
+	errTestKeepAliveFailed           = errors.New("fakeSSHConn: keepalive failed")
+)
+
+// fakeSSHConn is a minimal ssh.Conn stand-in that lets tests control
+// SendRequest/OpenChannel behavior without a real SSH handshake.
+type fakeSSHConn struct {
+	sendRequest func(name string, wantReply bool, payload []byte) (bool, []byte, error)
+	openChannel func(name string, data []byte) (ssh.Channel, <-chan *ssh.Request, error)
+	closed      int32
+}
+
+func (f *fakeSSHConn) User() string          { return "fake" }
+func (f *fakeSSHConn) SessionID() []byte     { return nil }
+func (f *fakeSSHConn) ClientVersion() []byte { return nil }
+func (f *fakeSSHConn) ServerVersion() []byte { return nil }
+func (f *fakeSSHConn) RemoteAddr() net.Addr  { return &net.TCPAddr{} }
+func (f *fakeSSHConn) LocalAddr() net.Addr   { return &net.TCPAddr{} }
+func (f *fakeSSHConn) Wait() error           { return nil }
+func (f *fakeSSHConn) Close() error {
+	atomic.StoreInt32(&f.closed, 1)
+	return nil
+}
+func (f *fakeSSHConn) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	if f.sendRequest != nil {
+		return f.sendRequest(name, wantReply, payload)
+	}
+	return true, nil, nil
+}
+func (f *fakeSSHConn) OpenChannel(name string, data []byte) (ssh.Channel, <-chan *ssh.Request, error) {
+	if f.openChannel != nil {
+		return f.openChannel(name, data)
+	}
+	return nil, nil, errTestOpenChannelNotImplemented
+}
+
+// fakeNetConn is a net.Conn stand-in that records SetDeadline calls and
+// supports Close, which is all remoteConn needs from it in tests.
+type fakeNetConn struct {
+	net.Conn
+	closed         int32
+	deadlineCalled int32
+}
+
+func (f *fakeNetConn) SetDeadline(time.Time) error {
+	atomic.StoreInt32(&f.deadlineCalled, 1)
+	return nil
+}
+func (f *fakeNetConn) Close() error {
+	atomic.StoreInt32(&f.closed, 1)
+	return nil
+}
+func (f *fakeNetConn) RemoteAddr() net.Addr { return &net.TCPAddr{} }
+
+func newTestRemoteConn(sshConn *fakeSSHConn) *remoteConn {
+	rc, _ := newRemoteConn(log.WithField("test", "srv_test"), &fakeNetConn{}, sshConn)
+	return rc
+}
+
+// TestKeepAliveLoopEvictsDeadConnection verifies that once a remoteConn
+// misses countMax consecutive keepalives, keepAliveLoop marks it invalid and
+// closes its underlying net.Conn, and that GetStatus stops reporting Online
+// for a site whose only connection never passed a keepalive.
+func TestKeepAliveLoopEvictsDeadConnection(t *testing.T) {
+	t.Parallel()
+
+	sshConn := &fakeSSHConn{
+		sendRequest: func(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+			return false, nil, errTestKeepAliveFailed
+		},
+	}
+	rc := newTestRemoteConn(sshConn)
+
+	site := &tunnelSite{connections: []*remoteConn{rc}}
+	require.Equal(t, RemoteSiteStatusOffline, site.GetStatus())
+
+	done := make(chan struct{})
+	go func() {
+		rc.keepAliveLoop(5*time.Millisecond, 3)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("keepAliveLoop did not return after exhausting countMax")
+	}
+
+	require.True(t, rc.isInvalid())
+	require.Equal(t, int32(1), atomic.LoadInt32(&rc.conn.(*fakeNetConn).closed))
+	require.Equal(t, RemoteSiteStatusOffline, site.GetStatus())
+}
+
+// TestRecordKeepAliveSuccessReportsOnline verifies that recording a
+// successful keepalive marks the connection as having passed its first
+// keepalive, which is what GetStatus requires before reporting a site
+// Online. The success is recorded by hand; keepAliveLoop itself is only
+// checked to return once the connection is stopped.
+func TestRecordKeepAliveSuccessReportsOnline(t *testing.T) {
+	t.Parallel()
+
+	sshConn := &fakeSSHConn{}
+	rc := newTestRemoteConn(sshConn)
+	require.False(t, rc.passedKeepAlive())
+
+	rc.stop()
+	rc.keepAliveLoop(time.Hour, 3)
+
+	// keepAliveLoop returns immediately once stopC is closed, before its
+	// first tick, so drive a single success manually the same way the loop
+	// would on its first successful tick.
+	ok, _, err := rc.sshConn.SendRequest(keepAliveRequest, true, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	rc.recordKeepAliveSuccess()
+
+	require.True(t, rc.passedKeepAlive())
+	site := &tunnelSite{connections: []*remoteConn{rc}}
+	require.Equal(t, RemoteSiteStatusOnline, site.GetStatus())
+}
+
+// TestSendKeepAliveDoesNotShareDeadlineWithForegroundDial verifies a
+// keepalive that blocks past its timeout is reported as a miss without
+// touching rc.conn's deadline, so a concurrent foreground dial relying on
+// that same deadline isn't cut short by an unrelated keepalive tick.
+func TestSendKeepAliveDoesNotShareDeadlineWithForegroundDial(t *testing.T) {
+	t.Parallel()
+
+	unblock := make(chan struct{})
+	sshConn := &fakeSSHConn{
+		sendRequest: func(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+			<-unblock
+			return true, nil, nil
+		},
+	}
+	defer close(unblock)
+	rc := newTestRemoteConn(sshConn)
+
+	ok, err := rc.sendKeepAlive(10 * time.Millisecond)
+	require.Error(t, err)
+	require.False(t, ok)
+
+	netConn := rc.conn.(*fakeNetConn)
+	require.Equal(t, int32(0), atomic.LoadInt32(&netConn.closed))
+	require.Equal(t, int32(0), atomic.LoadInt32(&netConn.deadlineCalled))
+}
+
+// TestNextConnPrefersHealthierConnection verifies nextConn's power-of-two-
+// choices scheduler favors the connection with the lower score (fewer
+// in-flight requests/errors, lower latency) over many draws, rather than
+// picking uniformly at random the way a plain round-robin would.
+func TestNextConnPrefersHealthierConnection(t *testing.T) {
+	t.Parallel()
+
+	healthy := newTestRemoteConn(&fakeSSHConn{})
+	unhealthy := newTestRemoteConn(&fakeSSHConn{})
+	unhealthy.statsMu.Lock()
+	unhealthy.errorCount = 10
+	unhealthy.ewmaLatency = time.Second
+	unhealthy.statsMu.Unlock()
+
+	site := &tunnelSite{connections: []*remoteConn{healthy, unhealthy}}
+
+	healthyPicks := 0
+	const trials = 200
+	for i := 0; i < trials; i++ {
+		conn, err := site.nextConn()
+		require.NoError(t, err)
+		if conn == healthy {
+			healthyPicks++
+		}
+	}
+
+	// With two candidates and power-of-two-choices, the healthier connection
+	// should win comfortably more than half the time.
+	require.Greater(t, healthyPicks, trials/2)
+}
+
+// TestNextConnPrunesInvalidConnections verifies that a connection marked
+// invalid by keepAliveLoop is pruned from rotation instead of being handed
+// out by nextConn.
+func TestNextConnPrunesInvalidConnections(t *testing.T) {
+	t.Parallel()
+
+	good := newTestRemoteConn(&fakeSSHConn{})
+	bad := newTestRemoteConn(&fakeSSHConn{})
+	bad.markInvalid()
+
+	site := &tunnelSite{connections: []*remoteConn{good, bad}}
+	conn, err := site.nextConn()
+	require.NoError(t, err)
+	require.Same(t, good, conn)
+	require.Len(t, site.connections, 1)
+}
+
+// TestDialContextReturnsImmediatelyOnCancellation verifies DialContext
+// honors an already-cancelled context instead of retrying forever, which
+// matters for callers like handleAuthProxy that tie a dial to a request
+// context and need to stop waiting once the client disconnects.
+func TestDialContextReturnsImmediatelyOnCancellation(t *testing.T) {
+	t.Parallel()
+
+	site := &tunnelSite{
+		log:         log.WithField("test", "TestDialContextReturnsImmediatelyOnCancellation"),
+		connections: []*remoteConn{newTestRemoteConn(&fakeSSHConn{})},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := site.DialContext(ctx, "tcp", "example.com:22")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+// TestTunnelListenerCloseUnregistersAndSendsUnlisten verifies
+// tunnelListener.Close de-registers the listener from its site so a later
+// Listen for the same addr can succeed, and sends chanTransportUnlisten to
+// the agent so it stops forwarding to a listener nobody is reading from
+// anymore.
+func TestTunnelListenerCloseUnregistersAndSendsUnlisten(t *testing.T) {
+	t.Parallel()
+
+	var gotUnlisten int32
+	sshConn := &fakeSSHConn{
+		sendRequest: func(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+			if name == chanTransportUnlisten {
+				atomic.StoreInt32(&gotUnlisten, 1)
+			}
+			return true, nil, nil
+		},
+	}
+	rc := newTestRemoteConn(sshConn)
+
+	site := &tunnelSite{
+		log:       log.WithField("test", "TestTunnelListenerCloseUnregistersAndSendsUnlisten"),
+		listeners: make(map[string]*tunnelListener),
+	}
+	listener := &tunnelListener{
+		site:       site,
+		remoteConn: rc,
+		addr:       tunnelAddr{network: "unix", addr: "/tmp/test.sock"},
+		connC:      make(chan net.Conn),
+		closeC:     make(chan struct{}),
+	}
+	site.listeners[listener.addr.addr] = listener
+
+	require.NoError(t, listener.Close())
+
+	_, ok := site.getListener(listener.addr.addr)
+	require.False(t, ok)
+	require.Equal(t, int32(1), atomic.LoadInt32(&gotUnlisten))
+}